@@ -0,0 +1,125 @@
+package rrule
+
+import "time"
+
+// Between returns the occurrences of the pattern after a and before b. If inc
+// is true, occurrences equal to a or b are included. An invalid pattern has no
+// occurrences.
+//
+// Unless the pattern has a COUNT, expansion skips straight to the period
+// containing a instead of starting at Dtstart.
+func (rrule RRule) Between(a, b time.Time, inc bool) []time.Time {
+	it, err := rrule.iterator()
+	if err != nil {
+		return nil
+	}
+	it.SkipTo(a)
+	return between(it, a, b, inc)
+}
+
+// After returns the first occurrence of the pattern after t. If inc is true,
+// an occurrence equal to t may be returned. The boolean is false if there is
+// no such occurrence.
+func (rrule RRule) After(t time.Time, inc bool) (time.Time, bool) {
+	it, err := rrule.iterator()
+	if err != nil {
+		return time.Time{}, false
+	}
+	it.SkipTo(t)
+	return after(it, t, inc)
+}
+
+// Before returns the last occurrence of the pattern before t. If inc is true,
+// an occurrence equal to t may be returned. The boolean is false if there is
+// no such occurrence.
+//
+// The pattern is expanded in reverse from t, so COUNT is the only bound that
+// requires scanning forward from Dtstart.
+func (rrule RRule) Before(t time.Time, inc bool) (time.Time, bool) {
+	it, err := rrule.reverseIterator(t)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return before(it, t, inc)
+}
+
+// Between returns the occurrences of the recurrence after a and before b. If
+// inc is true, occurrences equal to a or b are included. An invalid recurrence
+// has no occurrences.
+//
+// Patterns without a COUNT skip straight to the period containing a instead of
+// starting at Dtstart.
+func (r Recurrence) Between(a, b time.Time, inc bool) []time.Time {
+	it, err := r.iterator()
+	if err != nil {
+		return nil
+	}
+	it.SkipTo(a)
+	return between(it, a, b, inc)
+}
+
+// After returns the first occurrence of the recurrence after t. If inc is
+// true, an occurrence equal to t may be returned. The boolean is false if there
+// is no such occurrence.
+func (r Recurrence) After(t time.Time, inc bool) (time.Time, bool) {
+	it, err := r.iterator()
+	if err != nil {
+		return time.Time{}, false
+	}
+	it.SkipTo(t)
+	return after(it, t, inc)
+}
+
+// Before returns the last occurrence of the recurrence before t. If inc is
+// true, an occurrence equal to t may be returned. The boolean is false if there
+// is no such occurrence.
+func (r Recurrence) Before(t time.Time, inc bool) (time.Time, bool) {
+	it, err := r.reverseIterator(t)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return before(it, t, inc)
+}
+
+func between(it timeSource, a, b time.Time, inc bool) []time.Time {
+	var all []time.Time
+	for {
+		next, ok := it.next()
+		if !ok {
+			break
+		}
+		if next.After(b) || (!inc && next.Equal(b)) {
+			break
+		}
+		if next.Before(a) || (!inc && next.Equal(a)) {
+			continue
+		}
+		all = append(all, next)
+	}
+	return all
+}
+
+func after(it timeSource, t time.Time, inc bool) (time.Time, bool) {
+	for {
+		next, ok := it.next()
+		if !ok {
+			return time.Time{}, false
+		}
+		if next.After(t) || (inc && next.Equal(t)) {
+			return next, true
+		}
+	}
+}
+
+// before returns the first time of the descending iterator it that is before t.
+func before(it timeSource, t time.Time, inc bool) (time.Time, bool) {
+	for {
+		next, ok := it.next()
+		if !ok {
+			return time.Time{}, false
+		}
+		if next.Before(t) || (inc && next.Equal(t)) {
+			return next, true
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package rrule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var windowRRules = []string{
+	"FREQ=SECONDLY;INTERVAL=7",
+	"FREQ=SECONDLY;BYSECOND=5,35,50",
+	"FREQ=MINUTELY;INTERVAL=13;BYSECOND=10,20",
+	"FREQ=HOURLY;INTERVAL=5;BYMINUTE=0,30",
+	"FREQ=DAILY;INTERVAL=3;BYHOUR=8,20",
+	"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+	"FREQ=MONTHLY;BYDAY=-1FR",
+	"FREQ=MONTHLY;BYMONTHDAY=1,15",
+	"FREQ=YEARLY;BYMONTH=8,9;BYDAY=MO,TU,WE,TH,FR,SA,SU;BYSETPOS=1,-1",
+	"FREQ=DAILY;COUNT=150",
+}
+
+func TestBetween(t *testing.T) {
+	for _, str := range windowRRules {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = now
+
+			all := All(rrule.Iterator(), 150)
+			require.Len(t, all, 150)
+
+			for _, window := range [][2]int{{0, 10}, {17, 18}, {40, 149}, {99, 99}} {
+				a, b := all[window[0]], all[window[1]]
+
+				t.Run(fmt.Sprintf("%d-%d", window[0], window[1]), func(t *testing.T) {
+					assert.Equal(t, all[window[0]:window[1]+1], rrule.Between(a, b, true))
+
+					exclusive := rrule.Between(a, b, false)
+					if window[1]-window[0] <= 1 {
+						assert.Empty(t, exclusive)
+					} else {
+						assert.Equal(t, all[window[0]+1:window[1]], exclusive)
+					}
+
+					got, ok := rrule.After(a, true)
+					assert.True(t, ok)
+					assert.Equal(t, a, got)
+
+					got, ok = rrule.After(a, false)
+					assert.True(t, ok)
+					assert.Equal(t, all[window[0]+1], got)
+
+					got, ok = rrule.After(a.Add(-time.Nanosecond), false)
+					assert.True(t, ok)
+					assert.Equal(t, a, got)
+
+					got, ok = rrule.Before(b, true)
+					assert.True(t, ok)
+					assert.Equal(t, b, got)
+
+					got, ok = rrule.Before(b, false)
+					if window[1] == 0 {
+						assert.False(t, ok)
+					} else {
+						assert.True(t, ok)
+						assert.Equal(t, all[window[1]-1], got)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestBetweenFarFuture(t *testing.T) {
+	rrule := RRule{
+		Frequency: Minutely,
+		Interval:  7,
+		BySeconds: []int{15},
+		Dtstart:   time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	a := time.Date(2500, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := a.Add(30 * time.Minute)
+
+	// 2500-01-01T00:00:00Z is 5 minutes past a multiple of 7 minutes since Dtstart.
+	assert.Equal(t, []string{
+		"2500-01-01T00:02:15Z",
+		"2500-01-01T00:09:15Z",
+		"2500-01-01T00:16:15Z",
+		"2500-01-01T00:23:15Z",
+	}, rfcAll(rrule.Between(a, b, true)))
+}
+
+func TestRecurrenceBetween(t *testing.T) {
+	dtstart := now.Truncate(time.Second)
+	r := Recurrence{
+		Dtstart: dtstart,
+		RRules:  []RRule{{Frequency: Daily}},
+		RDates:  []time.Time{time.Date(2018, 9, 3, 12, 0, 0, 0, time.UTC), time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)},
+		ExRules: []RRule{{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: time.Sunday}}}},
+		ExDates: []time.Time{time.Date(2018, 9, 4, 9, 8, 7, 0, time.UTC)},
+	}
+
+	a := time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)
+	b := time.Date(2018, 9, 5, 9, 8, 7, 0, time.UTC)
+
+	assert.Equal(t, []string{
+		"2018-09-01T12:00:00Z",
+		"2018-09-03T09:08:07Z",
+		"2018-09-03T12:00:00Z",
+		"2018-09-05T09:08:07Z",
+	}, rfcAll(r.Between(a, b, true)))
+	assert.Equal(t, []string{
+		"2018-09-03T09:08:07Z",
+		"2018-09-03T12:00:00Z",
+	}, rfcAll(r.Between(a, b, false)))
+
+	got, ok := r.After(time.Date(2018, 9, 3, 9, 8, 7, 0, time.UTC), false)
+	assert.True(t, ok)
+	assert.Equal(t, "2018-09-03T12:00:00Z", got.Format(time.RFC3339))
+
+	got, ok = r.Before(time.Date(2018, 9, 5, 9, 8, 7, 0, time.UTC), false)
+	assert.True(t, ok)
+	assert.Equal(t, "2018-09-03T12:00:00Z", got.Format(time.RFC3339))
+
+	_, ok = r.Before(dtstart, false)
+	assert.False(t, ok)
+}
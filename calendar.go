@@ -0,0 +1,268 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Event is a recurring VEVENT or VTODO of an iCalendar object, together with
+// the components that override its individual occurrences.
+type Event struct {
+	// Component is the name of the component, VEVENT or VTODO.
+	Component string
+
+	// UID identifies the event. Components that share a UID are grouped into
+	// one Event.
+	UID string
+
+	// Recurrence holds the DTSTART, RRULE, EXRULE, RDATE, and EXDATE of the
+	// event. It is empty if the calendar only holds overrides for the event.
+	Recurrence Recurrence
+
+	// End is the DTEND of a VEVENT or the DUE of a VTODO, if it has one.
+	// Otherwise, Duration is its DURATION, if it has one.
+	End      time.Time
+	Duration time.Duration
+
+	// Overrides are the components with a RECURRENCE-ID, which replace the
+	// occurrence they name, in the order they appear.
+	Overrides []Override
+}
+
+// Override is a component that replaces one occurrence of an Event, as named
+// by its RECURRENCE-ID.
+type Override struct {
+	// RecurrenceID is the occurrence being replaced.
+	RecurrenceID time.Time
+
+	// ThisAndFuture is true if the RECURRENCE-ID has RANGE=THISANDFUTURE,
+	// meaning the override applies to later occurrences as well.
+	ThisAndFuture bool
+
+	// Start is the DTSTART of the override, which is RecurrenceID if it
+	// doesn't have one. End and Duration are like those of Event.
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+
+	// Cancelled is true if the override has STATUS:CANCELLED, meaning the
+	// occurrence is removed rather than moved.
+	Cancelled bool
+}
+
+// Occurrences returns the recurrence of the event with its overrides applied.
+// The occurrence named by each override is excluded, and the start of each
+// override is included unless it is cancelled. Overrides with ThisAndFuture are
+// applied only to the occurrence they name.
+func (e Event) Occurrences() Recurrence {
+	r := e.Recurrence
+	r.RDates = slices.Clone(r.RDates)
+	r.ExDates = slices.Clone(r.ExDates)
+
+	for _, o := range e.Overrides {
+		r.ExDates = append(r.ExDates, o.RecurrenceID)
+		if !o.Cancelled {
+			r.RDates = append(r.RDates, o.Start)
+		}
+	}
+	return r
+}
+
+// ParseCalendar parses the VEVENT and VTODO components of an iCalendar object,
+// such as a whole .ics file. Components are grouped by UID into one Event
+// each, in the order their UIDs first appear. Components without a UID are
+// each their own Event.
+//
+// Times are parsed as by ParseRecurrence, with loc for floating times, and
+// TZIDs are resolved against the VTIMEZONE components of src first. Other
+// components, including those nested in an event such as VALARM, are ignored.
+func ParseCalendar(src []byte, loc *time.Location) ([]Event, error) {
+	lines, err := parseContentLines(src)
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := parseComponents(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	z, err := timezones(components)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &calendarParser{loc: loc, zones: z, byUID: map[string]int{}, masters: map[int]bool{}}
+	if err := p.walk(components); err != nil {
+		return nil, err
+	}
+	return p.events, nil
+}
+
+// calendarParser collects the events of ParseCalendar.
+type calendarParser struct {
+	loc    *time.Location
+	zones  zones
+	events []Event
+
+	// byUID indexes events by UID, and masters records which events have had
+	// their component without a RECURRENCE-ID added.
+	byUID   map[string]int
+	masters map[int]bool
+}
+
+// walk adds the VEVENTs and VTODOs among components and their descendants.
+func (p *calendarParser) walk(components []*component) error {
+	for _, c := range components {
+		if c.name != "VEVENT" && c.name != "VTODO" {
+			if err := p.walk(c.children); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.add(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add adds a VEVENT or VTODO, either as a new Event or as the master or an
+// override of the Event with the same UID.
+func (p *calendarParser) add(c *component) error {
+	line, hasUID := c.prop("UID")
+	uid := unescapeText(line.value)
+
+	idx, found := p.byUID[uid]
+	if !hasUID || !found {
+		idx = len(p.events)
+		p.events = append(p.events, Event{Component: c.name, UID: uid})
+		if hasUID {
+			p.byUID[uid] = idx
+		}
+	}
+	event := &p.events[idx]
+
+	end, duration, err := parseEnd(c, p.zones, p.loc)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := c.prop("RECURRENCE-ID"); ok {
+		o := Override{End: end, Duration: duration}
+
+		o.RecurrenceID, _, _, err = parseDateOrTimeLine(id, p.zones, p.loc)
+		if err != nil {
+			return err
+		}
+		if rng, ok := id.param("RANGE"); ok {
+			o.ThisAndFuture = strings.EqualFold(rng, "THISANDFUTURE")
+		}
+
+		o.Start = o.RecurrenceID
+		if start, ok := c.prop("DTSTART"); ok {
+			o.Start, _, _, err = parseDateOrTimeLine(start, p.zones, p.loc)
+			if err != nil {
+				return err
+			}
+		}
+
+		if status, ok := c.prop("STATUS"); ok {
+			o.Cancelled = strings.EqualFold(status.value, "CANCELLED")
+		}
+
+		event.Overrides = append(event.Overrides, o)
+		return nil
+	}
+
+	if p.masters[idx] {
+		return fmt.Errorf("%s with UID %q appears more than once without a RECURRENCE-ID", c.name, uid)
+	}
+	p.masters[idx] = true
+
+	r, err := parseRecurrenceLines(c.props, p.zones, p.loc)
+	if err != nil {
+		return err
+	}
+
+	event.Component = c.name
+	event.Recurrence = *r
+	event.End = end
+	event.Duration = duration
+	return nil
+}
+
+// parseEnd parses the DTEND, DUE, or DURATION of a component.
+func parseEnd(c *component, z zones, loc *time.Location) (time.Time, time.Duration, error) {
+	for _, name := range []string{"DTEND", "DUE"} {
+		if line, ok := c.prop(name); ok {
+			end, _, _, err := parseDateOrTimeLine(line, z, loc)
+			return end, 0, err
+		}
+	}
+
+	if line, ok := c.prop("DURATION"); ok {
+		d, err := parseDuration(line.value)
+		return time.Time{}, d, err
+	}
+
+	return time.Time{}, 0, nil
+}
+
+// component is an iCalendar component, such as a VCALENDAR or VEVENT, holding
+// its own properties and the components nested in it.
+type component struct {
+	name     string
+	props    []contentLine
+	children []*component
+}
+
+// prop returns the first property of c with the given name.
+func (c *component) prop(name string) (contentLine, bool) {
+	for _, p := range c.props {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return contentLine{}, false
+}
+
+// parseComponents nests lines into the components delimited by their BEGIN
+// and END lines. Properties outside of any component are dropped.
+func parseComponents(lines []contentLine) ([]*component, error) {
+	root := &component{}
+	stack := []*component{root}
+
+	for _, line := range lines {
+		top := stack[len(stack)-1]
+
+		switch line.name {
+		case "BEGIN":
+			if line.value == "" {
+				return nil, errors.New("BEGIN must name a component")
+			}
+			c := &component{name: strings.ToUpper(line.value)}
+			top.children = append(top.children, c)
+			stack = append(stack, c)
+		case "END":
+			if top == root || !strings.EqualFold(line.value, top.name) {
+				return nil, fmt.Errorf("END:%s does not end an open component", line.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if top != root {
+				top.props = append(top.props, line)
+			}
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("BEGIN:%s is never ended", stack[len(stack)-1].name)
+	}
+
+	return root.children, nil
+}
@@ -0,0 +1,182 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//Calendar//EN\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/New_York\r\n" +
+	"BEGIN:STANDARD\r\n" +
+	"DTSTART:19701101T020000\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\r\n" +
+	"TZOFFSETFROM:-0400\r\n" +
+	"TZOFFSETTO:-0500\r\n" +
+	"END:STANDARD\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"SUMMARY:Standup\r\n" +
+	"DTSTART;TZID=America/New_York:20240108T093000\r\n" +
+	"DTEND;TZID=America/New_York:20240108T094500\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=6\r\n" +
+	"EXDATE;TZID=America/New_York:20240110T093000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"TRIGGER:-PT10M\r\n" +
+	"DURATION:PT5M\r\n" +
+	"RRULE:FREQ=DAILY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20240101\r\n" +
+	"DURATION:P1D\r\n" +
+	"RRULE:FREQ=YEARLY;COUNT=3\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20240115T093000\r\n" +
+	"DTSTART;TZID=America/New_York:20240116T100000\r\n" +
+	"DTEND;TZID=America/New_York:20240116T101500\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York;RANGE=THISANDFUTURE:20240117T093000\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:report@example.com\r\n" +
+	"DTSTART:20240105T170000Z\r\n" +
+	"DUE:20240105T180000Z\r\n" +
+	"RRULE:FREQ=MONTHLY;COUNT=2\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseCalendar(t *testing.T) {
+	events, err := ParseCalendar([]byte(testCalendar), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	standup := events[0]
+	assert.Equal(t, "VEVENT", standup.Component)
+	assert.Equal(t, "standup@example.com", standup.UID)
+	assert.True(t, time.Date(2024, 1, 8, 9, 45, 0, 0, NewYork()).Equal(standup.End))
+	assert.Zero(t, standup.Duration)
+	assert.Len(t, standup.Recurrence.RRules, 1)
+
+	assert.Equal(t, []string{
+		"2024-01-08T09:30:00-05:00",
+		"2024-01-15T09:30:00-05:00",
+		"2024-01-17T09:30:00-05:00",
+		"2024-01-22T09:30:00-05:00",
+		"2024-01-24T09:30:00-05:00",
+	}, rfcAll(All(standup.Recurrence.Iterator(), 0)))
+
+	require.Len(t, standup.Overrides, 2)
+	moved := standup.Overrides[0]
+	assert.True(t, time.Date(2024, 1, 15, 9, 30, 0, 0, NewYork()).Equal(moved.RecurrenceID))
+	assert.True(t, time.Date(2024, 1, 16, 10, 0, 0, 0, NewYork()).Equal(moved.Start))
+	assert.True(t, time.Date(2024, 1, 16, 10, 15, 0, 0, NewYork()).Equal(moved.End))
+	assert.False(t, moved.Cancelled)
+	assert.False(t, moved.ThisAndFuture)
+
+	cancelled := standup.Overrides[1]
+	assert.True(t, cancelled.Cancelled)
+	assert.True(t, cancelled.ThisAndFuture)
+	assert.True(t, cancelled.RecurrenceID.Equal(cancelled.Start))
+
+	occurrences := standup.Occurrences()
+	assert.Equal(t, []string{
+		"2024-01-08T09:30:00-05:00",
+		"2024-01-16T10:00:00-05:00",
+		"2024-01-22T09:30:00-05:00",
+		"2024-01-24T09:30:00-05:00",
+	}, rfcAll(All(occurrences.Iterator(), 0)))
+	assert.Len(t, standup.Recurrence.ExDates, 1, "Occurrences must not modify the event")
+
+	holiday := events[1]
+	assert.True(t, holiday.Recurrence.AllDay)
+	assert.Equal(t, 24*time.Hour, holiday.Duration)
+	assert.Equal(t, []string{
+		"2024-01-01T00:00:00Z",
+		"2025-01-01T00:00:00Z",
+		"2026-01-01T00:00:00Z",
+	}, rfcAll(All(holiday.Recurrence.Iterator(), 0)))
+
+	report := events[2]
+	assert.Equal(t, "VTODO", report.Component)
+	assert.Equal(t, time.Date(2024, 1, 5, 18, 0, 0, 0, time.UTC), report.End)
+	assert.Equal(t, []string{
+		"2024-01-05T17:00:00Z",
+		"2024-02-05T17:00:00Z",
+	}, rfcAll(All(report.Recurrence.Iterator(), 0)))
+}
+
+func TestParseCalendarOverridesOnly(t *testing.T) {
+	src := "BEGIN:VEVENT\n" +
+		"UID:a\n" +
+		"RECURRENCE-ID:20240101T090000Z\n" +
+		"DTSTART:20240101T100000Z\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"DTSTART:20240102T090000Z\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"DTSTART:20240103T090000Z\n" +
+		"END:VEVENT\n"
+
+	events, err := ParseCalendar([]byte(src), nil)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "a", events[0].UID)
+	assert.True(t, events[0].Recurrence.Dtstart.IsZero())
+	assert.Len(t, events[0].Overrides, 1)
+
+	assert.Equal(t, "", events[1].UID)
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), events[1].Recurrence.Dtstart)
+	assert.Equal(t, time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), events[2].Recurrence.Dtstart)
+}
+
+func TestParseCalendarEscapedUID(t *testing.T) {
+	// UIDs are TEXT, so they are compared and returned unescaped.
+	src := "BEGIN:VEVENT\n" +
+		"UID:a\\,b\\;c\\Nd\n" +
+		"DTSTART:20240101T090000Z\n" +
+		"RRULE:FREQ=DAILY;COUNT=2\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:a\\,b\\;c\\nd\n" +
+		"RECURRENCE-ID:20240102T090000Z\n" +
+		"DTSTART:20240102T100000Z\n" +
+		"END:VEVENT\n"
+
+	events, err := ParseCalendar([]byte(src), nil)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "a,b;c\nd", events[0].UID)
+	assert.Len(t, events[0].Overrides, 1)
+}
+
+func TestParseCalendarErrors(t *testing.T) {
+	for _, src := range []string{
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VEVENT\nEND:VEVENT\nEND:VEVENT\n",
+		"BEGIN:VEVENT\n",
+		"BEGIN:\nEND:\n",
+		"BEGIN:VEVENT\nUID:a\nEND:VEVENT\nBEGIN:VEVENT\nUID:a\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART:bad\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDURATION:1H\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nUID:a\nRECURRENCE-ID:bad\nEND:VEVENT\n",
+	} {
+		_, err := ParseCalendar([]byte(src), nil)
+		assert.Error(t, err, src)
+	}
+}
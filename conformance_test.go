@@ -0,0 +1,24 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stephens2424/rrule/rrtest"
+)
+
+func TestConformance(t *testing.T) {
+	rrtest.Run(t, func(e rrtest.Example, limit int) ([]time.Time, error) {
+		if e.Calendar != "GREGORIAN" {
+			return nil, fmt.Errorf("%s calendar: %w", e.Calendar, errors.ErrUnsupported)
+		}
+
+		r, err := ParseRecurrence([]byte(e.Recurrence), nil)
+		if err != nil {
+			return nil, err
+		}
+		return All(r.Iterator(), limit), nil
+	})
+}
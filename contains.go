@@ -0,0 +1,116 @@
+package rrule
+
+import "time"
+
+// Contains reports whether t is an occurrence of the pattern. An invalid
+// pattern has no occurrences.
+//
+// Instead of iterating from Dtstart, Contains expands only the period
+// containing t, and its neighbors if invalid behavior could push an instance
+// across a period boundary. Shortly after clocks are set forward, the periods
+// of the local times they skipped are expanded too. Only a pattern with COUNT
+// requires scanning from Dtstart, and then only once t is known to match the
+// pattern otherwise.
+func (rrule RRule) Contains(t time.Time) bool {
+	it, err := rrule.iterator()
+	if err != nil {
+		return false
+	}
+
+	if t.Before(it.minTime) || t.After(it.maxTime) {
+		return false
+	}
+
+	first, last := it.periodsOf(t)
+	found := false
+	for p := first; p <= last && !found; p++ {
+		found = it.expandsTo(p, t)
+	}
+	if !found && rrule.InvalidBehavior != OmitInvalid {
+		found = it.expandsTo(first-1, t) || it.expandsTo(last+1, t)
+	}
+	if !found {
+		return false
+	}
+
+	if rrule.Count > 0 {
+		it.SkipTo(t)
+		next := it.Peek()
+		return next != nil && next.Equal(t)
+	}
+
+	return true
+}
+
+// expandsTo reports whether t is among the occurrences of the nth period of a
+// fresh iterator, before any minimum, maximum, or count is applied.
+func (i *iterator) expandsTo(n int, t time.Time) bool {
+	if n < i.period {
+		return false
+	}
+
+	key, ok := i.key(n)
+	if !ok {
+		return false
+	}
+
+	i.keyTime = key
+	if !i.valid(&i.keyTime) {
+		return false
+	}
+
+	for _, v := range i.occurrences(key) {
+		if v.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether t is an occurrence of the recurrence: it is an
+// RDATE or an occurrence of one of the RRules, and it is neither an EXDATE nor
+// an occurrence of one of the ExRules. A recurrence with an invalid pattern
+// has no occurrences.
+//
+// Each pattern is checked with RRule.Contains, so no pattern is iterated
+// unless it has a COUNT.
+func (r Recurrence) Contains(t time.Time) bool {
+	if r.setDtstart() != nil {
+		return false
+	}
+
+	for _, rr := range r.RRules {
+		if rr.Validate() != nil {
+			return false
+		}
+	}
+	for _, rr := range r.ExRules {
+		if rr.Validate() != nil {
+			return false
+		}
+	}
+
+	for _, exdate := range r.ExDates {
+		if exdate.Equal(t) {
+			return false
+		}
+	}
+	for _, exrule := range r.ExRules {
+		if exrule.Contains(t) {
+			return false
+		}
+	}
+
+	for _, rdate := range r.rdates() {
+		if rdate.Equal(t) {
+			return true
+		}
+	}
+	for _, rrule := range r.RRules {
+		if rrule.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
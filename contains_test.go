@@ -0,0 +1,107 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContains(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			all := All(tc.RRule.Iterator(), 51)
+			included := map[time.Time]bool{}
+			for _, d := range all {
+				included[d] = true
+			}
+
+			if len(all) > 50 {
+				all = all[:50]
+			}
+
+			for _, d := range all {
+				assert.True(t, tc.RRule.Contains(d), d)
+				assert.Equal(t, included[d.Add(time.Second)], tc.RRule.Contains(d.Add(time.Second)), d)
+				assert.False(t, tc.RRule.Contains(d.Add(-time.Nanosecond)), d)
+			}
+		})
+	}
+}
+
+func TestContainsBySetPosFallBack(t *testing.T) {
+	// BYSETPOS selects from the hour clocks repeat separately from its first
+	// occurrence.
+	first := time.Date(2018, time.November, 4, 5, 30, 0, 0, time.UTC).In(NewYork())
+	second := first.Add(time.Hour)
+
+	rrule := MustRRule("FREQ=HOURLY;BYMINUTE=0,30;BYSETPOS=-1")
+	rrule.Dtstart = time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork())
+	assert.True(t, rrule.Contains(first))
+	assert.True(t, rrule.Contains(second))
+	assert.False(t, rrule.Contains(second.Add(-30*time.Minute)))
+
+	rrule = MustRRule("FREQ=HOURLY;BYMINUTE=30;BYSETPOS=2")
+	rrule.Dtstart = time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork())
+	assert.False(t, rrule.Contains(first))
+	assert.False(t, rrule.Contains(second))
+}
+
+func TestContainsWindow(t *testing.T) {
+	for _, str := range windowRRules {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = now
+
+			all := All(rrule.Iterator(), 160)
+
+			// check a few hundred evenly spaced seconds between a sample of
+			// occurrences, and every second for the shorter gaps
+			for i := 0; i < 20; i++ {
+				step := all[i+1].Sub(all[i]).Truncate(time.Second) / 300
+				if step < time.Second {
+					step = time.Second
+				}
+				for d := all[i]; !d.After(all[i+1]); d = d.Add(step) {
+					assert.Equal(t, d.Equal(all[i]) || d.Equal(all[i+1]), rrule.Contains(d), d)
+				}
+				assert.True(t, rrule.Contains(all[i+1]))
+				assert.False(t, rrule.Contains(all[i+1].Add(-time.Second)))
+			}
+
+			assert.False(t, rrule.Contains(now.Add(-24*time.Hour)))
+
+			if rrule.Count != 0 {
+				assert.True(t, rrule.Contains(all[149]))
+				assert.False(t, rrule.Contains(all[149].AddDate(0, 0, 1)))
+			}
+		})
+	}
+}
+
+func TestRecurrenceContains(t *testing.T) {
+	for _, tc := range recurrenceCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			included := map[time.Time]bool{}
+			for _, d := range All(tc.Recurrence.Iterator(), 0) {
+				included[d] = true
+				assert.True(t, tc.Recurrence.Contains(d), d)
+			}
+
+			for d := now; d.Before(now.AddDate(0, 0, 20)); d = d.AddDate(0, 0, 1) {
+				assert.Equal(t, included[d], tc.Recurrence.Contains(d), d)
+			}
+		})
+	}
+
+	r := Recurrence{
+		Dtstart: now,
+		RRules:  []RRule{{Frequency: Daily}},
+		ExRules: []RRule{{Frequency: Frequency(12)}},
+	}
+	assert.False(t, r.Contains(now))
+}
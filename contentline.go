@@ -0,0 +1,218 @@
+package rrule
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// contentLine is a single property of an iCalendar object, as defined by
+// section 3.1 of RFC 5545:
+//
+//	name *(";" param ) ":" value CRLF
+//
+// Names are case-insensitive, so name and the names of params are upper-cased.
+// value is left exactly as written, since how it is escaped depends on its type.
+type contentLine struct {
+	name   string
+	params []contentParam
+	value  string
+}
+
+// contentParam is a parameter of a content line. Quotes are removed from its
+// values, and RFC 6868 escapes are decoded.
+type contentParam struct {
+	name   string
+	values []string
+}
+
+// param returns the first value of the named parameter.
+func (cl contentLine) param(name string) (string, bool) {
+	for _, p := range cl.params {
+		if p.name == name && len(p.values) > 0 {
+			return p.values[0], true
+		}
+	}
+	return "", false
+}
+
+// unfold splits src into lines, joining any line that begins with a space or
+// horizontal tab to the line before it, as described in section 3.1 of RFC
+// 5545. Lines may end in CRLF or in a bare LF. Empty lines are dropped.
+func unfold(src []byte) []string {
+	var lines []string
+	var current []byte
+	inLine := false
+
+	for _, raw := range bytes.Split(src, []byte{'\n'}) {
+		raw = bytes.TrimSuffix(raw, []byte{'\r'})
+
+		if inLine && len(raw) > 0 && (raw[0] == ' ' || raw[0] == '\t') {
+			current = append(current, raw[1:]...)
+			continue
+		}
+
+		if inLine && len(current) > 0 {
+			lines = append(lines, string(current))
+		}
+		current = append(current[:0], raw...)
+		inLine = true
+	}
+
+	if inLine && len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+
+	return lines
+}
+
+// parseContentLines unfolds src and parses each of its lines.
+func parseContentLines(src []byte) ([]contentLine, error) {
+	var lines []contentLine
+	for _, text := range unfold(src) {
+		cl, err := parseContentLine(text)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, cl)
+	}
+	return lines, nil
+}
+
+// parseContentLine parses a single unfolded content line.
+func parseContentLine(text string) (contentLine, error) {
+	cl := contentLine{}
+
+	name, rest := splitName(text)
+	if name == "" {
+		return cl, fmt.Errorf("misformatted line %q: missing property name", text)
+	}
+	cl.name = strings.ToUpper(name)
+
+	for len(rest) > 0 && rest[0] == ';' {
+		var p contentParam
+		var err error
+		p, rest, err = parseParam(rest[1:])
+		if err != nil {
+			return cl, fmt.Errorf("misformatted line %q: %v", text, err)
+		}
+		cl.params = append(cl.params, p)
+	}
+
+	if len(rest) == 0 || rest[0] != ':' {
+		return cl, fmt.Errorf("misformatted line %q: missing ':' before the value", text)
+	}
+
+	cl.value = rest[1:]
+	return cl, nil
+}
+
+// parseParam parses a parameter from the beginning of s, returning the rest.
+//
+//	param = param-name "=" param-value *("," param-value)
+func parseParam(s string) (contentParam, string, error) {
+	p := contentParam{}
+
+	name, rest := splitName(s)
+	if name == "" {
+		return p, s, fmt.Errorf("missing parameter name")
+	}
+	p.name = strings.ToUpper(name)
+
+	if len(rest) == 0 || rest[0] != '=' {
+		return p, s, fmt.Errorf("parameter %s has no value", p.name)
+	}
+	rest = rest[1:]
+
+	for {
+		var value string
+		if len(rest) > 0 && rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return p, s, fmt.Errorf("parameter %s has an unterminated quoted value", p.name)
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexAny(rest, ",;:\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+
+		p.values = append(p.values, decodeParamValue(value))
+
+		if len(rest) == 0 || rest[0] != ',' {
+			return p, rest, nil
+		}
+		rest = rest[1:]
+	}
+}
+
+// splitName splits s after the longest prefix of characters allowed in a name,
+// which are letters, digits, and "-".
+func splitName(s string) (name, rest string) {
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		if c != '-' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			break
+		}
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// decodeParamValue decodes the escapes of RFC 6868 in a parameter value: ^n for
+// a newline, ^' for a double quote, and ^^ for a caret.
+func decodeParamValue(s string) string {
+	if !strings.Contains(s, "^") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '^' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\'':
+				b.WriteByte('"')
+				i++
+				continue
+			case '^':
+				b.WriteByte('^')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// unescapeText decodes a TEXT value, as described in section 3.3.11 of RFC
+// 5545.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i+1])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
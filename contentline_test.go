@@ -0,0 +1,124 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnfold(t *testing.T) {
+	src := "DESCRIPTION:This is a lo\r\n ng description\r\n\tthat exists on a long line.\r\nSUMMARY:short\nX-EMPTY:\n\n \nRRULE:FREQ=DAILY\n"
+	assert.Equal(t, []string{
+		"DESCRIPTION:This is a long descriptionthat exists on a long line.",
+		"SUMMARY:short",
+		"X-EMPTY:",
+		"RRULE:FREQ=DAILY",
+	}, unfold([]byte(src)))
+}
+
+func TestParseContentLine(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected contentLine
+	}{
+		{
+			Input:    "RRULE:FREQ=DAILY;COUNT=3",
+			Expected: contentLine{name: "RRULE", value: "FREQ=DAILY;COUNT=3"},
+		},
+		{
+			Input: "dtstart;tzid=America/New_York:19970902T090000",
+			Expected: contentLine{
+				name:   "DTSTART",
+				params: []contentParam{{name: "TZID", values: []string{"America/New_York"}}},
+				value:  "19970902T090000",
+			},
+		},
+		{
+			Input: `ATTENDEE;DELEGATED-TO="mailto:jdoe@example.com","mailto:jqpublic@example.com";RSVP=TRUE:mailto:jsmith@example.com`,
+			Expected: contentLine{
+				name: "ATTENDEE",
+				params: []contentParam{
+					{name: "DELEGATED-TO", values: []string{"mailto:jdoe@example.com", "mailto:jqpublic@example.com"}},
+					{name: "RSVP", values: []string{"TRUE"}},
+				},
+				value: "mailto:jsmith@example.com",
+			},
+		},
+		{
+			Input: `X-LOC;X-NAME="a;b:c":value;with:separators`,
+			Expected: contentLine{
+				name:   "X-LOC",
+				params: []contentParam{{name: "X-NAME", values: []string{"a;b:c"}}},
+				value:  "value;with:separators",
+			},
+		},
+		{
+			Input: `X-CARET;X-NAME="^'quoted^' ^^ on^ntwo lines":x`,
+			Expected: contentLine{
+				name:   "X-CARET",
+				params: []contentParam{{name: "X-NAME", values: []string{"\"quoted\" ^ on\ntwo lines"}}},
+				value:  "x",
+			},
+		},
+		{
+			Input:    "X-EMPTY:",
+			Expected: contentLine{name: "X-EMPTY"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			got, err := parseContentLine(tc.Input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, got)
+		})
+	}
+
+	for _, input := range []string{
+		"",
+		"RRULE",
+		":FREQ=DAILY",
+		"RRULE;FREQ=DAILY",
+		"DTSTART;TZID:20180101T000000",
+		`DTSTART;TZID="America/New_York:20180101T000000`,
+		`DTSTART;TZID=America/"New_York":20180101T000000`,
+		"BAD NAME:value",
+	} {
+		_, err := parseContentLine(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	assert.Equal(t, "plain", unescapeText("plain"))
+	assert.Equal(t, "a, b; c\\d\ne\nf", unescapeText(`a\, b\; c\\d\ne\Nf`))
+}
+
+func TestParseRecurrenceContentLines(t *testing.T) {
+	src := "BEGIN:VEVENT\r\n" +
+		"SUMMARY;LANGUAGE=en-US:Team meeting\\, weekly\r\n" +
+		"dtstart;VALUE=DATE-TIME;TZID=\"America/New_York\":20180825T\r\n 090807\r\n" +
+		"Rrule:FREQ=WEEKLY;\r\n COUNT=3\r\n" +
+		"X-IGNORED;X-PARAM=\"a:b;c\":ignored:value\r\n" +
+		"RDATE;TZID=America/New_York:20180827T100000\r\n" +
+		"exdate:20180901T130807Z\r\n" +
+		"END:VEVENT\r\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	assert.True(t, time.Date(2018, 8, 25, 9, 8, 7, 0, NewYork()).Equal(r.Dtstart))
+	assert.Equal(t, NewYork().String(), r.Dtstart.Location().String())
+	assert.False(t, r.FloatingLocation)
+
+	assert.Equal(t, []string{
+		"2018-08-25T09:08:07-04:00",
+		"2018-08-27T10:00:00-04:00",
+		"2018-09-08T09:08:07-04:00",
+	}, rfcAll(All(r.Iterator(), 0)))
+
+	_, err = ParseRecurrence([]byte("DTSTART:20180825T090807Z\nRRULE\n"), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package rrule
+
+import "time"
+
+// CountOccurrences returns the number of occurrences of the pattern. The
+// boolean is false if the pattern is invalid or is bounded by neither UNTIL nor
+// COUNT, in which case its occurrences never end.
+//
+// Patterns with no BYxxx rule parts are counted arithmetically. Others are
+// counted a period at a time, without collecting their occurrences.
+func (rrule RRule) CountOccurrences() (int, bool) {
+	if rrule.Until.IsZero() && rrule.Count == 0 {
+		return 0, false
+	}
+
+	it, err := rrule.iterator()
+	if err != nil {
+		return 0, false
+	}
+
+	if it.arithmetic {
+		return it.arithmeticCount(), true
+	}
+
+	return it.count(), true
+}
+
+// Last returns the last occurrence of the pattern. The boolean is false if the
+// pattern is invalid, has no occurrences, or is bounded by neither UNTIL nor
+// COUNT.
+//
+// Patterns with no BYxxx rule parts are computed arithmetically. Otherwise, a
+// pattern bounded by UNTIL is expanded in reverse from UNTIL, and one bounded
+// by COUNT is scanned forward from Dtstart.
+func (rrule RRule) Last() (time.Time, bool) {
+	if rrule.Until.IsZero() && rrule.Count == 0 {
+		return time.Time{}, false
+	}
+
+	it, err := rrule.iterator()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if it.arithmetic {
+		n := it.arithmeticCount()
+		if n == 0 {
+			return time.Time{}, false
+		}
+		return it.occurrence(n - 1)
+	}
+
+	rev, err := rrule.reverseIterator(time.Time{})
+	if err != nil {
+		return time.Time{}, false
+	}
+	return rev.next()
+}
+
+// CountOccurrences returns the number of occurrences of the recurrence, after
+// RDATEs are added and EXDATEs and EXRULEs are removed. The boolean is false if
+// the recurrence is invalid or any of its RRules is bounded by neither UNTIL nor
+// COUNT.
+//
+// A recurrence that is a single RRule is counted like the RRule. Otherwise, the
+// recurrence is scanned from its first occurrence without collecting them.
+func (r Recurrence) CountOccurrences() (int, bool) {
+	if r.setDtstart() != nil {
+		return 0, false
+	}
+
+	for _, rr := range r.RRules {
+		if rr.Until.IsZero() && rr.Count == 0 {
+			return 0, false
+		}
+	}
+
+	if len(r.RRules) == 1 && len(r.RDates) == 0 && len(r.RPeriods) == 0 && len(r.ExRules) == 0 && len(r.ExDates) == 0 {
+		return r.RRules[0].CountOccurrences()
+	}
+
+	it, err := r.iterator()
+	if err != nil {
+		return 0, false
+	}
+
+	n := 0
+	for _, ok := it.next(); ok; _, ok = it.next() {
+		n++
+	}
+	return n, true
+}
+
+// Last returns the last occurrence of the recurrence. The boolean is false if
+// the recurrence is invalid, has no occurrences, or any of its RRules is
+// bounded by neither UNTIL nor COUNT.
+func (r Recurrence) Last() (time.Time, bool) {
+	rev, err := r.reverseIterator(time.Time{})
+	if err != nil {
+		return time.Time{}, false
+	}
+	return rev.next()
+}
+
+// arithmeticCount returns the number of occurrences of a fresh arithmetic
+// iterator, which must be bounded by its count or maximum time.
+func (i *iterator) arithmeticCount() int {
+	if i.queueCap > 0 {
+		if last, ok := i.occurrence(int(i.queueCap) - 1); ok && !last.After(i.maxTime) {
+			return int(i.queueCap)
+		}
+	}
+	return i.countBefore(i.maxTime, true)
+}
+
+// count consumes i, counting its times a period at a time.
+func (i *iterator) count() int {
+	n := 0
+	for _, ok := i.peek(); ok; _, ok = i.peek() {
+		n += len(i.queue)
+		i.queue = nil
+	}
+	return n
+}
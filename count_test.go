@@ -0,0 +1,121 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountOccurrences(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			n, ok := tc.RRule.CountOccurrences()
+			last, lastOK := tc.RRule.Last()
+
+			if tc.RRule.Count == 0 && tc.RRule.Until.IsZero() {
+				assert.False(t, ok)
+				assert.False(t, lastOK)
+				return
+			}
+
+			all := All(tc.RRule.Iterator(), 0)
+			assert.True(t, ok)
+			assert.Equal(t, len(all), n)
+
+			if len(all) == 0 {
+				assert.False(t, lastOK)
+				return
+			}
+			assert.True(t, lastOK)
+			assert.True(t, all[len(all)-1].Equal(last), "%s != %s", all[len(all)-1], last)
+		})
+	}
+}
+
+func TestCountOccurrencesArithmetic(t *testing.T) {
+	dtstart := time.Date(2018, 3, 10, 1, 30, 0, 0, NewYork())
+
+	for _, str := range []string{
+		"FREQ=SECONDLY;INTERVAL=7;UNTIL=20180311T120000Z",
+		"FREQ=MINUTELY;INTERVAL=13;COUNT=500",
+		"FREQ=HOURLY;INTERVAL=5;UNTIL=20190101T000000Z",
+		"FREQ=DAILY;INTERVAL=3;UNTIL=20200310T013000-0400",
+		"FREQ=WEEKLY;INTERVAL=2;COUNT=40",
+		"FREQ=MONTHLY;UNTIL=20300101T000000Z",
+		"FREQ=YEARLY;INTERVAL=4;UNTIL=21000101T000000Z",
+		"FREQ=DAILY;UNTIL=20180301T000000Z",
+		"FREQ=DAILY;UNTIL=20180310T013000-0500",
+	} {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = dtstart
+
+			all := All(rrule.Iterator(), 0)
+
+			n, ok := rrule.CountOccurrences()
+			assert.True(t, ok)
+			assert.Equal(t, len(all), n)
+
+			last, ok := rrule.Last()
+			if len(all) == 0 {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.True(t, all[len(all)-1].Equal(last), "%s != %s", all[len(all)-1], last)
+		})
+	}
+}
+
+func TestRecurrenceCountOccurrences(t *testing.T) {
+	r := Recurrence{
+		Dtstart: now,
+		RRules: []RRule{
+			{Frequency: Daily, ByHours: []int{9, 18}, Until: now.AddDate(0, 1, 0)},
+			{Frequency: Weekly, Count: 10},
+		},
+		RDates:  []time.Time{now.AddDate(1, 0, 1), now.AddDate(0, 0, 2)},
+		ExDates: []time.Time{now.AddDate(0, 0, 7)},
+		ExRules: []RRule{{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: time.Sunday}}}},
+	}
+
+	all := All(r.Iterator(), 0)
+
+	n, ok := r.CountOccurrences()
+	assert.True(t, ok)
+	assert.Equal(t, len(all), n)
+
+	last, ok := r.Last()
+	assert.True(t, ok)
+	assert.Equal(t, now.AddDate(1, 0, 1), last)
+
+	single := Recurrence{Dtstart: now, RRules: []RRule{{Frequency: Monthly, Count: 7}}}
+	n, ok = single.CountOccurrences()
+	assert.True(t, ok)
+	assert.Equal(t, 7, n)
+
+	last, ok = single.Last()
+	assert.True(t, ok)
+	assert.Equal(t, now.AddDate(0, 6, 0), last)
+
+	r.RRules = append(r.RRules, RRule{Frequency: Daily})
+	_, ok = r.CountOccurrences()
+	assert.False(t, ok)
+	_, ok = r.Last()
+	assert.False(t, ok)
+
+	for _, tc := range recurrenceCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			all := All(tc.Recurrence.Iterator(), 0)
+
+			n, ok := tc.Recurrence.CountOccurrences()
+			assert.True(t, ok)
+			assert.Equal(t, len(all), n)
+		})
+	}
+}
@@ -33,7 +33,9 @@ func (rrule RRule) Describe() string {
 	if rrule.WeekStart != nil {
 		fmt.Fprintf(b, ", with weeks starting on %v", rrule.WeekStart)
 	}
-	if !rrule.Until.IsZero() {
+	if rrule.UntilDate && !rrule.Until.IsZero() {
+		fmt.Fprintf(b, ", until %v", rrule.Until.Format("Mon Jan _2 2006"))
+	} else if !rrule.Until.IsZero() {
 		fmt.Fprintf(b, ", until %v", rrule.Until.Format(time.UnixDate))
 	}
 	byMonthDesc(b, rrule.ByMonths)
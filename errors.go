@@ -0,0 +1,114 @@
+package rrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FrequencyError is returned when a Frequency is not one of the constants
+// defined by this package.
+type FrequencyError struct {
+	Frequency Frequency
+}
+
+func (e *FrequencyError) Error() string {
+	return fmt.Sprintf("%d is not a supported frequency constant", int(e.Frequency))
+}
+
+// IteratorError is returned when an RRule within a group could not produce an
+// iterator. Err holds the underlying reason.
+type IteratorError struct {
+	RRule RRule
+	Err   error
+}
+
+func (e *IteratorError) Error() string {
+	return fmt.Sprintf("rrule could not produce an iterator: %v", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *IteratorError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists every problem found when validating an RRule.
+type ValidationError struct {
+	Problems []ValidationProblem
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0].String()
+	}
+
+	strs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		strs[i] = p.String()
+	}
+	return fmt.Sprintf("%d problems: %s", len(e.Problems), strings.Join(strs, "; "))
+}
+
+// Unwrap returns the errors underlying the problems, so that errors.As finds
+// them.
+func (e *ValidationError) Unwrap() []error {
+	var errs []error
+	for _, p := range e.Problems {
+		if p.Err != nil {
+			errs = append(errs, p.Err)
+		}
+	}
+	return errs
+}
+
+// ValidationProblem describes a single invalid part of an RRule.
+type ValidationProblem struct {
+	// Part names the offending rule part, like "BYDAY" or "COUNT/UNTIL".
+	Part string
+
+	// Value is the offending value, formatted as it would be in an RRULE.
+	Value string
+
+	// Section is the RFC 5545 section that defines the broken constraint.
+	Section string
+
+	// Message describes the problem.
+	Message string
+
+	// Err is the error underlying the problem, if any, such as a
+	// *FrequencyError for FREQ.
+	Err error
+}
+
+func (p ValidationProblem) String() string {
+	return fmt.Sprintf("%s=%s: %s (RFC 5545 section %s)", p.Part, p.Value, p.Message, p.Section)
+}
+
+// recurSection is the RFC 5545 section defining the RECUR value type, which
+// holds every constraint on the parts of an RRULE.
+const recurSection = "3.3.10"
+
+func (e *ValidationError) add(part, value, message string) {
+	e.Problems = append(e.Problems, ValidationProblem{
+		Part:    part,
+		Value:   value,
+		Section: recurSection,
+		Message: message,
+	})
+}
+
+// checkRange adds a problem for every value of ints outside [min,max]. Zero is
+// only accepted if allowZero is true.
+func (e *ValidationError) checkRange(part string, ints []int, min, max int, allowZero bool) {
+	for _, n := range ints {
+		if (n == 0 && !allowZero) || n < min || n > max {
+			var msg string
+			if allowZero {
+				msg = fmt.Sprintf("%s values must be between [%d,%d]", part, min, max)
+			} else {
+				msg = fmt.Sprintf("%s values must be between [%d,-1] or [1,%d]", part, min, max)
+			}
+			e.add(part, strconv.Itoa(n), msg)
+		}
+	}
+}
@@ -1,135 +1,199 @@
 package rrule
 
 import (
-	"sort"
+	"slices"
 	"time"
 )
 
-func expandBySeconds(tt []time.Time, seconds ...int) []time.Time {
+// expansion holds the times produced while expanding a key time. Each step
+// reads the times of the previous step from tt and appends its own to spare,
+// and then the two swap places. An iterator expands every period with the same
+// expansion, so that once the buffers have grown, expanding allocates nothing.
+//
+// Times are expanded in wall-clock time, as if in UTC, so that adding hours,
+// minutes, and seconds never crosses a change of the clocks. The iterator
+// places them in the location of the pattern once they are expanded.
+type expansion struct {
+	tt    []time.Time
+	spare []time.Time
+}
+
+// reset begins a new expansion of the key time t.
+func (e *expansion) reset(t time.Time) {
+	e.tt = append(e.tt[:0], t)
+}
+
+// out returns an empty slice to append the times of the next step to.
+func (e *expansion) out() []time.Time {
+	return e.spare[:0]
+}
+
+// swap makes out, which was built from e.out(), the current times.
+func (e *expansion) swap(out []time.Time) {
+	e.tt, e.spare = out, e.tt
+}
+
+func (e *expansion) expandBySeconds(seconds ...int) {
 	if len(seconds) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(seconds))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		tmpl := t.Add(time.Duration(-1*t.Second()) * time.Second)
 		for _, s := range seconds {
 			if s < 0 {
 				s += 60
 			}
-			e = append(e, tmpl.Add((time.Duration(s) * time.Second)))
+			out = append(out, tmpl.Add((time.Duration(s) * time.Second)))
 		}
 	}
-
-	return e
+	e.swap(out)
 }
 
-func expandByMinutes(tt []time.Time, minutes ...int) []time.Time {
+func (e *expansion) expandByMinutes(minutes ...int) {
 	if len(minutes) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(minutes))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		tmpl := t.Add(time.Duration(-1*t.Minute()) * time.Minute)
 		for _, m := range minutes {
 			if m < 0 {
 				m += 60
 			}
-			e = append(e, tmpl.Add(time.Duration(m)*time.Minute))
+			out = append(out, tmpl.Add(time.Duration(m)*time.Minute))
 		}
 	}
-
-	return e
+	e.swap(out)
 }
 
-func expandByHours(tt []time.Time, hours ...int) []time.Time {
+func (e *expansion) expandByHours(hours ...int) {
 	if len(hours) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(hours))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		tmpl := t.Add(time.Duration(-1*t.Hour()) * time.Hour)
 		for _, h := range hours {
 			if h < 0 {
 				h += 24
 			}
-			e = append(e, tmpl.Add(time.Duration(h)*time.Hour))
+			out = append(out, tmpl.Add(time.Duration(h)*time.Hour))
 		}
 	}
-
-	return e
+	e.swap(out)
 }
 
-func expandByWeekdays(tt []time.Time, weekStart time.Weekday, weekdays ...QualifiedWeekday) []time.Time {
+func (e *expansion) expandByWeekdays(weekStart time.Weekday, weekdays ...QualifiedWeekday) {
 	if len(weekdays) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(weekdays))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		t = backToWeekday(t, weekStart)
 		for _, wd := range weekdays {
-			e = append(e, forwardToWeekday(t, wd.WD))
+			out = append(out, forwardToWeekday(t, wd.WD))
 		}
 	}
-
-	return e
+	e.swap(out)
 }
 
-func expandByMonthDays(tt []time.Time, monthdays ...int) []time.Time {
+// expandByMonthDays sets the day of each time to each of monthdays, in each of
+// months, or in its own month if there are none. Negative days count back from
+// the end of the month. Days the month does not have are handled according to
+// ib, as by resolveOrdinal.
+func (e *expansion) expandByMonthDays(ib InvalidBehavior, months []time.Month, monthdays ...int) {
 	if len(monthdays) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(monthdays))
-	for _, t := range tt {
-		for _, md := range monthdays {
-			e = append(e, time.Date(t.Year(), t.Month(), md, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()))
+	out := e.out()
+	for _, t := range e.tt {
+		own := [1]time.Month{t.Month()}
+		months := months
+		if len(months) == 0 {
+			months = own[:]
+		}
+
+		for _, m := range months {
+			first := time.Date(t.Year(), m, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+			days := lastOfMonth(first).Day()
+
+			for _, md := range monthdays {
+				if md, ok := resolveOrdinal(md, days, ib); ok {
+					out = append(out, first.AddDate(0, 0, md-1))
+				}
+			}
 		}
 	}
 
-	return e
+	slices.SortFunc(out, time.Time.Compare)
+	e.swap(slices.CompactFunc(out, time.Time.Equal))
 }
 
-func expandByYearDays(tt []time.Time, ib InvalidBehavior, yeardays ...int) []time.Time {
+// expandByYearDays sets the day of each time to each of yeardays in its year.
+// Negative days count back from the end of the year. Days the year does not
+// have are handled according to ib, as by resolveOrdinal.
+func (e *expansion) expandByYearDays(ib InvalidBehavior, yeardays ...int) {
 	if len(yeardays) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(yeardays))
-	for _, t := range tt {
-		yearStart := time.Date(t.Year(), time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
-		startYear := yearStart.Year()
+	out := e.out()
+	for _, t := range e.tt {
+		first := time.Date(t.Year(), time.January, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		days := daysInYear(t.Year())
 
 		for _, yd := range yeardays {
-			added := yearStart.AddDate(0, 0, yd-1) // subtract one because we start on the 1st, so if we want yearday 1, we actually want to advance 0.
-			if added.Year() != startYear {
-				switch ib {
-				case OmitInvalid:
-					// do nothing
-				case NextInvalid:
-					e = append(e, added)
-				case PrevInvalid:
-					e = append(e, added.AddDate(0, 0, -1))
-				}
-			} else {
-				e = append(e, added)
+			if yd, ok := resolveOrdinal(yd, days, ib); ok {
+				out = append(out, first.AddDate(0, 0, yd-1))
 			}
 		}
 	}
 
-	return e
+	slices.SortFunc(out, time.Time.Compare)
+	e.swap(slices.CompactFunc(out, time.Time.Equal))
 }
 
-func expandByWeekNumbers(tt []time.Time, ib InvalidBehavior, weekStarts time.Weekday, byWeekdays []time.Weekday, weekNumbers ...int) []time.Time {
+// resolveOrdinal returns the day or week of a month or year with count of
+// them that a BYMONTHDAY, BYYEARDAY, or BYWEEKNO value n selects, counting
+// negative values back from the last. One the month or year does not have is
+// handled according to ib, as the one after the last or before the first, or
+// not at all.
+func resolveOrdinal(n, count int, ib InvalidBehavior) (int, bool) {
+	if n < 0 {
+		n += count + 1
+	}
+	if n >= 1 && n <= count {
+		return n, true
+	}
+
+	switch ib {
+	case NextInvalid:
+		if n < 1 {
+			return 1, true
+		}
+		return count + 1, true
+	case PrevInvalid:
+		if n < 1 {
+			return 0, true
+		}
+		return count, true
+	}
+	return 0, false
+}
+
+func (e *expansion) expandByWeekNumbers(ib InvalidBehavior, weekStarts time.Weekday, byWeekdays []time.Weekday, weekNumbers ...int) {
 	if len(weekNumbers) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(weekNumbers))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		ys := yearStart(t, weekStarts)
 
 		byWeekdays := byWeekdays
@@ -139,105 +203,92 @@ func expandByWeekNumbers(tt []time.Time, ib InvalidBehavior, weekStarts time.Wee
 			// week. lib-recur seems to copy the weekday from the input
 			// time. I'm going with the latter, since it seems more consistent
 			// with the behavior you'd get on a BYMONTH clause.
-			byWeekdays = []time.Weekday{t.Weekday()}
+			own := [1]time.Weekday{t.Weekday()}
+			byWeekdays = own[:]
 		}
 
+		weeks := weeksInYear(t.Year(), weekStarts)
 		for _, w := range weekNumbers {
-			ws := ys.AddDate(0, 0, (w-1)*7)
-
-			if weekYearStart := yearStart(ws, weekStarts); weekYearStart.Year() != ys.Year() {
-				// check that the week we generated is still within the proper
-				// year, or if it ran over because the year did not have enough
-				// weeks
-
-				nextYearStart := yearStart(ys.AddDate(1, 0, 0), weekStarts)
-				switch ib {
-				case OmitInvalid:
-					// do nothing
-				case NextInvalid:
-					for _, wd := range byWeekdays {
-						e = append(e, forwardToWeekday(nextYearStart, wd))
-					}
-				case PrevInvalid:
-					for _, wd := range byWeekdays {
-						e = append(e, backToWeekday(nextYearStart, wd))
-					}
-				}
+			// weeks before the first are the last week of the previous year,
+			// and weeks after the last are the first week of the next.
+			w, ok := resolveOrdinal(w, weeks, ib)
+			if !ok {
 				continue
 			}
 
+			ws := ys.AddDate(0, 0, (w-1)*7)
 			for _, wd := range byWeekdays {
-				e = append(e, forwardToWeekday(ws, wd))
+				out = append(out, forwardToWeekday(ws, wd))
 			}
 		}
 	}
+	e.swap(out)
+}
 
-	return e
+// limit keeps only the times that are valid.
+func (e *expansion) limit(valid validFunc) {
+	out := e.out()
+	for i := range e.tt {
+		if valid(&e.tt[i]) {
+			out = append(out, e.tt[i])
+		}
+	}
+	e.swap(out)
 }
 
-func expandByMonths(tt []time.Time, ib InvalidBehavior, months ...time.Month) []time.Time {
+func (e *expansion) expandByMonths(ib InvalidBehavior, months ...time.Month) {
 	if len(months) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt)*len(months))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		for _, m := range months {
 			set := time.Date(t.Year(), m, t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
 			if set.Month() != m {
 				switch ib {
 				case PrevInvalid:
 					set = time.Date(t.Year(), t.Month()+1, -1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
-					e = append(e, set)
+					out = append(out, set)
 				case NextInvalid:
 					set = time.Date(t.Year(), t.Month()+1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
-					e = append(e, set)
+					out = append(out, set)
 				case OmitInvalid:
 					// do nothing
 				}
 			} else {
-				e = append(e, set)
+				out = append(out, set)
 			}
 		}
 	}
-
-	return e
+	e.swap(out)
 }
 
-// expandMonthByWeekdays does a special expansion of the month by weekdays. If
-// bySetPos is not nil, it is assumed tt is the full set of instances within the
-// monthly iteration, and only the instances matching the posisions of bySetPos
-// are returned. This is an optimization.
-func expandMonthByWeekdays(tt []time.Time, ib InvalidBehavior, bySetPos []int, weekdays ...QualifiedWeekday) []time.Time {
+// expandMonthByWeekdays does a special expansion of the month by weekdays.
+func (e *expansion) expandMonthByWeekdays(ib InvalidBehavior, weekdays ...QualifiedWeekday) {
 	if len(weekdays) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt))
-	for _, t := range tt {
-		e = append(e, weekdaysInMonth(t, weekdays, bySetPos, ib)...)
+	out := e.out()
+	for _, t := range e.tt {
+		out = weekdaysInMonth(out, t, weekdays, ib)
 	}
-
-	return e
+	e.swap(out)
 }
 
-func expandYearByWeekdays(tt []time.Time, ib InvalidBehavior, weekdays ...QualifiedWeekday) []time.Time {
+func (e *expansion) expandYearByWeekdays(ib InvalidBehavior, weekdays ...QualifiedWeekday) {
 	if len(weekdays) == 0 {
-		return tt
+		return
 	}
 
-	e := make([]time.Time, 0, len(tt))
-	for _, t := range tt {
+	out := e.out()
+	for _, t := range e.tt {
 		for _, wd := range weekdays {
-			res := weekdaysInYear(t, wd, ib)
-			e = append(e, res...)
+			out = weekdaysInYear(out, t, wd, ib)
 		}
 	}
 
-	sort.Slice(e, func(i, j int) bool {
-		return e[i].Before(e[j])
-	})
-
-	return e
-
+	slices.SortFunc(out, time.Time.Compare)
+	e.swap(out)
 }
@@ -1,30 +1,37 @@
 package rrule
 
-import "log"
-
 // Frequency defines a set of constants for a base factor for how often recurrences happen.
 type Frequency int
 
 // String returns the RFC 5545 string for supported frequencies, and panics otherwise.
 func (f Frequency) String() string {
+	str, err := freqToStr(f)
+	if err != nil {
+		panic(err)
+	}
+	return str
+}
+
+// freqToStr returns the RFC 5545 string for supported frequencies, and a
+// *FrequencyError otherwise.
+func freqToStr(f Frequency) (string, error) {
 	switch f {
 	case Secondly:
-		return "SECONDLY"
+		return "SECONDLY", nil
 	case Minutely:
-		return "MINUTELY"
+		return "MINUTELY", nil
 	case Hourly:
-		return "HOURLY"
+		return "HOURLY", nil
 	case Daily:
-		return "DAILY"
+		return "DAILY", nil
 	case Weekly:
-		return "WEEKLY"
+		return "WEEKLY", nil
 	case Monthly:
-		return "MONTHLY"
+		return "MONTHLY", nil
 	case Yearly:
-		return "YEARLY"
+		return "YEARLY", nil
 	}
-	log.Panicf("%d is not a supported frequency constant", f)
-	return ""
+	return "", &FrequencyError{Frequency: f}
 }
 
 // Frequencies specified in RFC 5545.
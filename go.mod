@@ -1,9 +1,14 @@
 module github.com/stephens2424/rrule
 
-go 1.12
+go 1.23
 
 require (
 	github.com/stretchr/testify v1.3.0
 	github.com/teambition/rrule-go v1.2.3
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/tools v0.0.0-20190228203856-589c23e65e65 // indirect
 )
@@ -1,77 +1,112 @@
 package rrule
 
-import (
-	"fmt"
-	"time"
-)
+import "time"
 
 type groupIterator struct {
-	currentMin *int
-	iters      []Iterator
+	// current is the index of the iterator holding the next time, if
+	// hasCurrent is true.
+	current    int
+	hasCurrent bool
+	iters      []*iterator
+
+	// descending is true if the iterators return times in descending order,
+	// in which case current holds the maximum instead of the minimum.
+	descending bool
 }
 
-func groupIteratorFromRRules(rrules []RRule) *groupIterator {
+// groupIteratorFromRRules combines the iterators of rrules. An *IteratorError
+// is returned for the first rule that cannot produce an iterator.
+func groupIteratorFromRRules(rrules []RRule) (*groupIterator, error) {
 	gi := &groupIterator{}
 	for _, rr := range rrules {
-		iter := rr.Iterator()
-		if iter == nil {
-			panic(fmt.Sprintf("rrule %q produced a nil iterator", rr))
-		}
-		if iter.(*iterator).next == nil {
-			panic(fmt.Sprintf("rrule %q produced a faulty iterator", rr))
+		iter, err := rr.iterator()
+		if err != nil {
+			return nil, &IteratorError{RRule: rr, Err: err}
 		}
-
 		gi.iters = append(gi.iters, iter)
 	}
 
-	return gi
+	return gi, nil
+}
+
+// SkipTo advances every iterator of the group to the first time at or after t,
+// or at or before t if the group is descending.
+func (gi *groupIterator) SkipTo(t time.Time) {
+	gi.hasCurrent = false
+	for _, iter := range gi.iters {
+		iter.SkipTo(t)
+	}
 }
 
 func (gi *groupIterator) Peek() *time.Time {
-	if gi.currentMin != nil {
-		return gi.iters[*gi.currentMin].Peek()
+	t, ok := gi.peek()
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (gi *groupIterator) Next() *time.Time {
+	t, ok := gi.next()
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (gi *groupIterator) peek() (time.Time, bool) {
+	if gi.hasCurrent {
+		return gi.iters[gi.current].peek()
 	}
 
-	var min *time.Time
+	var min time.Time
 	minIdx := -1
 
 	for i, iter := range gi.iters {
-		t := iter.Peek()
-		if t != nil {
-			if min == nil {
+		t, ok := iter.peek()
+		if ok {
+			if minIdx < 0 {
 				min = t
 				minIdx = i
 			} else {
-				if t.Before(*min) {
+				if gi.ahead(t, min) {
 					min = t
 					minIdx = i
 				} else if t.Truncate(time.Second).Equal(min.Truncate(time.Second)) {
 					// we equal the current minimum. we can safely
 					// skip this
-					iter.Next()
+					iter.next()
 				}
 			}
 		}
 	}
 
-	if minIdx >= 0 {
-		gi.currentMin = &minIdx
+	if minIdx < 0 {
+		return time.Time{}, false
 	}
 
-	return min
+	gi.current, gi.hasCurrent = minIdx, true
+	return min, true
 }
 
-func (gi *groupIterator) Next() *time.Time {
-	if gi.currentMin == nil {
-		gi.Peek()
+// ahead reports whether a comes before b in the order of the group.
+func (gi *groupIterator) ahead(a, b time.Time) bool {
+	if gi.descending {
+		return a.After(b)
 	}
+	return a.Before(b)
+}
 
-	if gi.currentMin == nil {
+func (gi *groupIterator) next() (time.Time, bool) {
+	if !gi.hasCurrent {
+		gi.peek()
+	}
+
+	if !gi.hasCurrent {
 		// still don't have a min time, so the iterators must all have ended
-		return nil
+		return time.Time{}, false
 	}
 
-	idx := *gi.currentMin
-	gi.currentMin = nil
-	return gi.iters[idx].Next()
+	gi.hasCurrent = false
+	return gi.iters[gi.current].next()
 }
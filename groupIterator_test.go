@@ -1,6 +1,7 @@
 package rrule
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,12 +9,13 @@ import (
 )
 
 func TestGroupIterator(t *testing.T) {
-	iter := groupIteratorFromRRules(
+	iter, err := groupIteratorFromRRules(
 		[]RRule{
 			MustRRule("FREQ=WEEKLY;COUNT=5;BYDAY=MO"),
 			MustRRule("FREQ=WEEKLY;COUNT=5;BYDAY=TU"),
 		},
 	)
+	require.NoError(t, err)
 
 	var count int
 	for {
@@ -33,3 +35,21 @@ func TestGroupIterator(t *testing.T) {
 	require.Equal(t, 10, count)
 
 }
+
+func TestGroupIteratorInvalid(t *testing.T) {
+	_, err := groupIteratorFromRRules(
+		[]RRule{
+			MustRRule("FREQ=WEEKLY;COUNT=5;BYDAY=MO"),
+			{Frequency: Frequency(42)},
+		},
+	)
+	require.Error(t, err)
+
+	var iterErr *IteratorError
+	require.True(t, errors.As(err, &iterErr))
+	assert.Equal(t, Frequency(42), iterErr.RRule.Frequency)
+
+	var valErr *ValidationError
+	require.True(t, errors.As(err, &valErr))
+	assert.Equal(t, "FREQ", valErr.Problems[0].Part)
+}
@@ -0,0 +1,73 @@
+package rrule
+
+import (
+	"time"
+)
+
+// floorDiv divides a by b, rounding toward negative infinity. b must be
+// positive.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// floorMod returns the remainder of floorDiv(a, b), which is never negative.
+func floorMod(a, b int) int {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
+
+// secondsBetween returns the number of whole seconds that elapse from a to b,
+// rounding toward negative infinity.
+func secondsBetween(a, b time.Time) int {
+	s := b.Unix() - a.Unix()
+	if b.Nanosecond() < a.Nanosecond() {
+		s--
+	}
+	return int(s)
+}
+
+// addSeconds adds s seconds to t. Unlike t.Add, it does not overflow when s
+// spans more than roughly 292 years.
+func addSeconds(t time.Time, s int) time.Time {
+	return time.Unix(t.Unix()+int64(s), int64(t.Nanosecond())).In(t.Location())
+}
+
+// daysBetween returns the number of calendar days from the date of a to the
+// date of b, both as observed in the location of a.
+func daysBetween(a, b time.Time) int {
+	b = b.In(a.Location())
+	ad := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	bd := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int((bd.Unix() - ad.Unix()) / (24 * 60 * 60))
+}
+
+// fixedPeriods numbers periods of unit seconds, spaced interval units apart
+// and beginning with the unit containing start. Key times keep the components
+// of start finer than unit.
+func fixedPeriods(start time.Time, unit, interval int) (key func(n int) (time.Time, bool), periodOf func(t time.Time) int) {
+	var offset int
+	switch unit {
+	case 60:
+		offset = start.Second()
+	case 60 * 60:
+		offset = start.Minute()*60 + start.Second()
+	}
+	origin := time.Unix(start.Unix()-int64(offset), 0)
+
+	key = func(n int) (time.Time, bool) {
+		return addSeconds(start, n*interval*unit), true
+	}
+
+	periodOf = func(t time.Time) int {
+		return floorDiv(floorDiv(secondsBetween(origin, t), unit), interval)
+	}
+
+	return key, periodOf
+}
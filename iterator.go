@@ -1,6 +1,7 @@
 package rrule
 
 import (
+	"slices"
 	"time"
 )
 
@@ -15,6 +16,107 @@ type Iterator interface {
 	Next() *time.Time
 }
 
+// TimeIterator scans over a series of times like Iterator, but returns each
+// time by value along with a boolean that is false once the series has ended.
+// Unlike an Iterator, a TimeIterator from this package does not allocate as it
+// returns times, which matters when expanding many patterns.
+type TimeIterator interface {
+	// Peek returns the next time without advancing the iterator.
+	Peek() (time.Time, bool)
+
+	// Next returns the next time and advances the iterator.
+	Next() (time.Time, bool)
+}
+
+// Times adapts it to a TimeIterator. Iterators from this package are adapted
+// without allocating for each time. The TimeIterator also implements Seeker.
+func Times(it Iterator) TimeIterator {
+	if src, ok := it.(timeSource); ok {
+		return timeIterator{src}
+	}
+	return timeIterator{pointerSource{it}}
+}
+
+// timeSource is implemented by the iterators of this package, which produce
+// times by value and wrap them in pointers only to implement Iterator.
+type timeSource interface {
+	peek() (time.Time, bool)
+	next() (time.Time, bool)
+}
+
+// timeIterator exports a timeSource as a TimeIterator.
+type timeIterator struct {
+	src timeSource
+}
+
+func (ti timeIterator) Peek() (time.Time, bool) { return ti.src.peek() }
+func (ti timeIterator) Next() (time.Time, bool) { return ti.src.next() }
+
+// SkipTo advances the iterator so that the next time returned is the first at
+// or after t, or for a reverse iterator, the first at or before t.
+func (ti timeIterator) SkipTo(t time.Time) {
+	if s, ok := ti.src.(Seeker); ok {
+		s.SkipTo(t)
+		return
+	}
+	skipByScanning(ti.src, t)
+}
+
+// pointerSource adapts an Iterator from outside this package to a timeSource.
+type pointerSource struct {
+	it Iterator
+}
+
+func (ps pointerSource) peek() (time.Time, bool) { return deref(ps.it.Peek()) }
+func (ps pointerSource) next() (time.Time, bool) { return deref(ps.it.Next()) }
+
+func deref(t *time.Time) (time.Time, bool) {
+	if t == nil {
+		return time.Time{}, false
+	}
+	return *t, true
+}
+
+// Seeker is implemented by iterators that can skip ahead without visiting
+// every time in between. All iterators returned by this package implement it.
+type Seeker interface {
+	// SkipTo advances the iterator so that the next time returned is the
+	// first at or after t, or for a reverse iterator, the first at or
+	// before t. SkipTo never moves an iterator backward.
+	SkipTo(t time.Time)
+}
+
+// OrdinalIterator is implemented by iterators that can report the position of
+// each time among all the times of the series. All iterators returned by this
+// package implement it.
+type OrdinalIterator interface {
+	Iterator
+
+	// Ordinal returns the zero-based position of the time the next call to
+	// Next will return, counted from the first time of the series. The
+	// boolean is false if the position is not known, as when SkipTo has
+	// passed over times without counting them.
+	Ordinal() (int, bool)
+}
+
+// SkipTo advances it so that the next time returned is the first at or after
+// t. If it is a Seeker, its SkipTo method is used. Otherwise, times before t
+// are consumed one at a time.
+func SkipTo(it Iterator, t time.Time) {
+	if s, ok := it.(Seeker); ok {
+		s.SkipTo(t)
+		return
+	}
+	skipByScanning(pointerSource{it}, t)
+}
+
+// skipByScanning consumes the times of an ascending iterator before t.
+func skipByScanning(it timeSource, t time.Time) {
+	for next, ok := it.peek(); ok && next.Before(t); next, ok = it.peek() {
+		it.next()
+	}
+}
+
 type iterator struct {
 	queue       []time.Time
 	totalQueued uint64
@@ -23,93 +125,337 @@ type iterator struct {
 	maxTime     time.Time
 	pastMaxTime bool
 
-	// next finds the next key time.
-	next func() *time.Time
+	// descending is true if the iterator was reversed. It then walks periods
+	// downward until it passes firstPeriod or minTime.
+	descending  bool
+	firstPeriod int
+	pastMinTime bool
+
+	// period is the index of the next period to expand. Periods are counted
+	// in intervals from the period containing Dtstart.
+	period int
+
+	// key returns the key time of the nth period. The boolean is false if
+	// that period has no key time, as when a monthly pattern keyed on the
+	// 31st reaches a shorter month.
+	key func(n int) (time.Time, bool)
+
+	// keyTime holds the key time being checked by valid, so that it can be
+	// passed by pointer without being allocated.
+	keyTime time.Time
+
+	// periodOf returns the index of the period containing t, which is
+	// negative if t precedes the first period.
+	periodOf func(t time.Time) int
 
-	// variations returns all the possible variations
-	// of the key time t
-	variations func(t *time.Time) []time.Time
+	// variations expands the key time t into all of its possible variations,
+	// using the buffers of e, in chronological order and without repetition.
+	// The result is only valid until the next expansion with e.
+	variations func(e *expansion, t time.Time) []time.Time
+	expansion  expansion
 
 	// valid determines if a particular key time is a valid recurrence.
 	valid func(t *time.Time) bool
 
-	setpos []int
+	// setpos holds the positions of BYSETPOS, which select among the
+	// variations of each period. If setPosUnit is set, they select among
+	// those of each elapsed period of that length instead, as the clocks of
+	// the placement read them, so that the hour clocks repeat is selected
+	// from separately from its first occurrence.
+	setpos     []int
+	setPosUnit time.Duration
+
+	// ordinal is the position of the next time in the series. It is only
+	// meaningful while lostOrdinal is false.
+	ordinal     int
+	lostOrdinal bool
+
+	// arithmetic is true if every period has exactly one occurrence, its key
+	// time, so that occurrences can be counted without expanding periods.
+	arithmetic bool
+
+	// placement places the wall-clock times of key and variations in the
+	// location of the pattern. It is nil for iterators over fixed times.
+	// periodOf then finds periods by the times clocks read, and wallPeriodOf
+	// is periodOf for those times.
+	placement    *placement
+	wallPeriodOf func(w time.Time) int
+
+	// repeats is true if a period may repeat times of earlier periods, which
+	// are then dropped along with any others before the last time queued.
+	repeats bool
+	last    time.Time
+
+	// bounds, if set, returns the earliest time that the periods from n
+	// onward may produce, and the latest that those up to n may. It is set
+	// when a period may produce times after those of later periods, and held
+	// then keeps them back until no later period can produce an earlier one.
+	bounds func(n int) (earliest, latest time.Time)
+	held   []time.Time
+	ready  []time.Time
 }
 
 func (i *iterator) Next() *time.Time {
-	t := i.Peek()
-	if len(i.queue) > 1 {
-		i.queue = i.queue[1:]
-	} else if len(i.queue) == 1 {
-		i.queue = nil
+	t, ok := i.next()
+	if !ok {
+		return nil
 	}
-	return t
+	return &t
 }
 
 func (i *iterator) Peek() *time.Time {
+	t, ok := i.peek()
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (i *iterator) next() (time.Time, bool) {
+	t, ok := i.peek()
+	if !ok {
+		return t, false
+	}
+
+	i.queue = i.queue[1:]
+	if i.descending {
+		i.ordinal--
+	} else {
+		i.ordinal++
+	}
+	return t, true
+}
+
+func (i *iterator) peek() (time.Time, bool) {
 	if len(i.queue) > 0 {
-		r := i.queue[0]
-		return &r
+		return i.queue[0], true
 	}
 
 	if i.queueCap > 0 {
 		if i.totalQueued >= i.queueCap {
-			return nil
+			return time.Time{}, false
 		}
 	}
 
-	if i.next == nil {
-		return nil
+	if i.key == nil {
+		return time.Time{}, false
 	}
 
 	for {
-		if i.pastMaxTime {
-			return nil
+		if i.pastMaxTime || i.pastMinTime || (i.descending && i.period < i.firstPeriod) {
+			// once no period is left, the times held back are next.
+			held := i.held
+			i.held = nil
+			return i.enqueue(held)
 		}
 
-		key := i.next()
-		if key == nil {
-			return nil
+		key, ok := i.key(i.period)
+		if i.descending {
+			i.period--
+		} else {
+			i.period++
+		}
+		if !ok {
+			continue
 		}
 
-		if !i.valid(key) {
+		i.keyTime = key
+		if !i.valid(&i.keyTime) {
 			continue
 		}
 
-		variations := i.variations(key)
+		variations := i.variations(&i.expansion, key)
 
-		// remove any variations before the min time
-		for len(variations) > 0 && variations[0].Before(i.minTime) {
-			variations = variations[1:]
+		// a period that BYSETPOS leaves without occurrences still ends
+		// iteration once it reaches past the minimum or maximum time.
+		if n := len(variations); n > 0 {
+			if i.descending && variations[0].Before(i.minTime) {
+				i.pastMinTime = true
+			}
+			if !i.descending && variations[n-1].After(i.maxTime) {
+				i.pastMaxTime = true
+			}
 		}
+		variations = i.limitBySetPos(variations)
 
-		// remove any variations after the max time
-		if !i.maxTime.IsZero() {
-			for idx, v := range variations {
-				if v.After(i.maxTime) {
-					variations = variations[:idx]
-					i.pastMaxTime = true
-					break
-				}
-			}
+		if i.descending {
+			variations = i.trimDescending(variations)
+		} else {
+			variations = i.trimAscending(variations)
 		}
 
-		// if we're left with nothing (or started there) skip this key time
-		if len(variations) == 0 {
-			continue
+		if i.bounds != nil {
+			variations = i.hold(variations)
+		}
+
+		if t, ok := i.enqueue(variations); ok {
+			return t, true
+		}
+	}
+}
+
+// occurrences returns the occurrences of the period with the key time key,
+// which are those of its variations at the positions of setpos, or all of them
+// if there is no BYSETPOS. As RFC 5545 specifies, BYSETPOS applies once every
+// other rule part has expanded or limited the period.
+func (i *iterator) occurrences(key time.Time) []time.Time {
+	return i.limitBySetPos(i.variations(&i.expansion, key))
+}
+
+// limitBySetPos keeps the variations of a period at the positions of setpos,
+// counted within each elapsed period if setPosUnit is set. The variations are
+// filtered in place.
+func (i *iterator) limitBySetPos(variations []time.Time) []time.Time {
+	if len(i.setpos) == 0 || i.setPosUnit == 0 {
+		return limitBySetPos(variations, i.setpos)
+	}
+
+	elapsedPeriod := func(t time.Time) (time.Time, int) {
+		_, offset := t.Zone()
+		return wallClock(t).Truncate(i.setPosUnit), offset
+	}
+
+	selected := variations[:0]
+	for len(variations) > 0 {
+		period, offset := elapsedPeriod(variations[0])
+		n := 1
+		for n < len(variations) {
+			p, o := elapsedPeriod(variations[n])
+			if !p.Equal(period) || o != offset {
+				break
+			}
+			n++
 		}
+		selected = append(selected, limitBySetPos(variations[:n], i.setpos)...)
+		variations = variations[n:]
+	}
+	return selected
+}
 
-		if i.queueCap > 0 {
-			if i.totalQueued+uint64(len(variations)) > i.queueCap {
-				variations = variations[:i.queueCap-i.totalQueued]
+// trimAscending removes the variations of a key time outside of the minimum
+// and maximum times.
+func (i *iterator) trimAscending(variations []time.Time) []time.Time {
+	// remove any variations before the min time
+	for len(variations) > 0 && variations[0].Before(i.minTime) {
+		variations = variations[1:]
+	}
+
+	// remove any variations after the max time
+	if !i.maxTime.IsZero() {
+		for idx, v := range variations {
+			if v.After(i.maxTime) {
+				variations = variations[:idx]
+				i.pastMaxTime = true
+				break
 			}
 		}
+	}
+
+	return variations
+}
 
-		i.totalQueued += uint64(len(variations))
+// hold adds the variations of a key time to the times held back, and returns
+// those of them that no later period can precede, in the order of iteration.
+func (i *iterator) hold(variations []time.Time) []time.Time {
+	i.held = append(i.held, variations...)
+	earliest, latest := i.bounds(i.period)
 
-		i.queue = variations[:]
-		return &variations[0]
+	var ready int
+	if i.descending {
+		slices.SortFunc(i.held, func(a, b time.Time) int {
+			return b.Compare(a)
+		})
+		i.held = slices.CompactFunc(i.held, time.Time.Equal)
+		for ready < len(i.held) && i.held[ready].After(latest) {
+			ready++
+		}
+		i.pastMinTime = latest.Before(i.minTime)
+	} else {
+		slices.SortFunc(i.held, time.Time.Compare)
+		i.held = slices.CompactFunc(i.held, time.Time.Equal)
+		for ready < len(i.held) && i.held[ready].Before(earliest) {
+			ready++
+		}
+		i.pastMaxTime = earliest.After(i.maxTime)
 	}
+
+	i.ready = append(i.ready[:0], i.held[:ready]...)
+	i.held = append(i.held[:0], i.held[ready:]...)
+	return i.ready
+}
+
+// enqueue queues the times of a period, which are in the order of iteration,
+// and returns the first of them. Times beyond the count are left out, as are
+// those repeated from earlier periods.
+func (i *iterator) enqueue(times []time.Time) (time.Time, bool) {
+	if i.repeats && !i.last.IsZero() {
+		for len(times) > 0 && (i.descending && !times[0].Before(i.last) || !i.descending && !times[0].After(i.last)) {
+			times = times[1:]
+		}
+	}
+
+	if len(times) == 0 {
+		return time.Time{}, false
+	}
+
+	if i.queueCap > 0 {
+		if i.totalQueued+uint64(len(times)) > i.queueCap {
+			times = times[:i.queueCap-i.totalQueued]
+		}
+	}
+
+	i.totalQueued += uint64(len(times))
+	i.last = times[len(times)-1]
+
+	i.queue = times
+	return times[0], true
+}
+
+// SkipTo advances the iterator so that the next time returned is the first at
+// or after t. Unless the pattern has a count, which requires every occurrence to
+// be counted, expansion jumps straight to the period containing t. A reversed
+// iterator instead advances to the first time at or before t.
+func (i *iterator) SkipTo(t time.Time) {
+	if i.descending {
+		i.skipBackTo(t)
+		return
+	}
+
+	if i.queueCap > 0 {
+		skipByScanning(i, t)
+		return
+	}
+
+	for len(i.queue) > 0 && i.queue[0].Before(t) {
+		i.queue = i.queue[1:]
+		i.ordinal++
+	}
+	for len(i.held) > 0 && i.held[0].Before(t) {
+		i.held = i.held[1:]
+	}
+
+	if len(i.queue) > 0 || !t.After(i.minTime) {
+		return
+	}
+
+	// times between here and t will be passed over without being counted,
+	// so the ordinal is lost unless it can be worked out directly.
+	if i.arithmetic {
+		i.ordinal = i.countBefore(t, false)
+	} else {
+		i.lostOrdinal = true
+	}
+
+	if i.periodOf != nil {
+		// begin one period early, because invalid behavior may push the
+		// last instance of a period into the next one. periods before
+		// i.period have already been expanded, so never go back to them.
+		if p := i.periodFrom(t) - 1; p > i.period {
+			i.period = p
+		}
+	}
+
+	i.minTime = t
 }
 
 // https://stackoverflow.com/questions/25065055/what-is-the-maximum-time-time-in-go
@@ -0,0 +1,164 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipTo(t *testing.T) {
+	for _, str := range windowRRules {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = now
+
+			all := All(rrule.Iterator(), 150)
+			require.Len(t, all, 150)
+
+			it := rrule.Iterator()
+			assert.Equal(t, all[0], *it.Next())
+			assert.Equal(t, all[1], *it.Peek())
+
+			SkipTo(it, all[20])
+			assert.Equal(t, all[20], *it.Next())
+
+			SkipTo(it, all[40].Add(time.Nanosecond))
+			assert.Equal(t, all[41], *it.Peek())
+
+			// skipping backward does nothing
+			SkipTo(it, all[10])
+			assert.Equal(t, all[41], *it.Next())
+			assert.Equal(t, all[42:149], All(it, 107))
+		})
+	}
+}
+
+func TestSkipToRecurrence(t *testing.T) {
+	r := Recurrence{
+		Dtstart: now,
+		RRules:  []RRule{{Frequency: Daily, ByHours: []int{9, 18}}, {Frequency: Weekly, Count: 30}},
+		RDates:  []time.Time{time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)},
+		ExRules: []RRule{{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: time.Sunday}}}},
+	}
+
+	all := All(r.Iterator(), 400)
+	require.Len(t, all, 400)
+
+	it := r.Iterator()
+	for _, idx := range []int{3, 4, 100, 101, 250, 399} {
+		SkipTo(it, all[idx])
+		assert.Equal(t, all[idx], *it.Peek())
+	}
+}
+
+// skipOnly hides the Seeker implementation of an Iterator.
+type skipOnly struct {
+	Iterator
+}
+
+func TestSkipToNonSeeker(t *testing.T) {
+	rrule := MustRRule("FREQ=HOURLY;INTERVAL=5")
+	rrule.Dtstart = now
+
+	all := All(rrule.Iterator(), 30)
+
+	it := skipOnly{rrule.Iterator()}
+	SkipTo(it, all[25].Add(-time.Second))
+	assert.Equal(t, all[25:], All(it, 5))
+}
+
+func BenchmarkSkipTo(b *testing.B) {
+	dtstart := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	target := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, str := range []string{
+		"FREQ=MINUTELY;INTERVAL=15;BYSECOND=0,30",
+		"FREQ=HOURLY;BYMINUTE=0,30",
+		"FREQ=DAILY;BYHOUR=9,17",
+	} {
+		rrule := MustRRule(str)
+		rrule.Dtstart = dtstart
+
+		b.Run(str, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				it := rrule.Iterator()
+				SkipTo(it, target)
+				it.Next()
+			}
+		})
+	}
+}
+
+func TestTimeIterator(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			expected := All(tc.RRule.Iterator(), 50)
+
+			it, err := tc.RRule.NewTimeIterator()
+			require.NoError(t, err)
+
+			var got []time.Time
+			for len(got) < len(expected) {
+				peeked, ok := it.Peek()
+				require.True(t, ok)
+				next, ok := it.Next()
+				require.True(t, ok)
+				assert.Equal(t, peeked, next)
+				got = append(got, next)
+			}
+			assert.Equal(t, expected, got)
+
+			if tc.Terminal {
+				_, ok := it.Next()
+				assert.False(t, ok)
+			}
+		})
+	}
+
+	_, err := RRule{Frequency: Frequency(12)}.NewTimeIterator()
+	assert.Error(t, err)
+}
+
+func TestTimes(t *testing.T) {
+	rrule := MustRRule("FREQ=HOURLY;INTERVAL=5")
+	rrule.Dtstart = now
+	all := All(rrule.Iterator(), 30)
+
+	for _, it := range []TimeIterator{Times(rrule.Iterator()), Times(skipOnly{rrule.Iterator()})} {
+		first, ok := it.Next()
+		assert.True(t, ok)
+		assert.Equal(t, all[0], first)
+
+		it.(Seeker).SkipTo(all[25].Add(-time.Second))
+		next, ok := it.Next()
+		assert.True(t, ok)
+		assert.Equal(t, all[25], next)
+	}
+}
+
+func TestTimeIteratorAllocs(t *testing.T) {
+	for _, str := range windowRRules {
+		rrule := MustRRule(str)
+		rrule.Dtstart = now
+		rrule.Count = 0
+
+		it, err := rrule.NewTimeIterator()
+		require.NoError(t, err)
+
+		// let the expansion buffers grow before measuring
+		for i := 0; i < 100; i++ {
+			it.Next()
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			it.Next()
+		})
+		assert.Zero(t, allocs, str)
+	}
+}
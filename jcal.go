@@ -0,0 +1,590 @@
+package rrule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The layouts of jCal DATE and DATE-TIME values, as described in section 3.5
+// of RFC 7265.
+const (
+	jcalDate     = "2006-01-02"
+	jcalDateTime = "2006-01-02T15:04:05"
+)
+
+var jcalTimeRegex = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})(?:T(\d{2}):(\d{2}):(\d{2})(Z?))?$`)
+
+// recurIntParts are the parts of a recur value whose values are integers,
+// which jCal writes as numbers.
+var recurIntParts = map[string]bool{
+	"COUNT":      true,
+	"INTERVAL":   true,
+	"BYSECOND":   true,
+	"BYMINUTE":   true,
+	"BYHOUR":     true,
+	"BYMONTHDAY": true,
+	"BYYEARDAY":  true,
+	"BYWEEKNO":   true,
+	"BYMONTH":    true,
+	"BYSETPOS":   true,
+}
+
+// MarshalJSON returns the frequency as a jCal string, such as "WEEKLY".
+func (f Frequency) MarshalJSON() ([]byte, error) {
+	str, err := freqToStr(f)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(str)
+}
+
+// UnmarshalJSON parses a jCal frequency string.
+func (f *Frequency) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+
+	freq, err := strToFreq(str)
+	if err != nil {
+		return err
+	}
+	*f = freq
+	return nil
+}
+
+// MarshalJSON returns the behavior as the jCal string of its RFC 7529 SKIP
+// value, such as "OMIT".
+func (ib InvalidBehavior) MarshalJSON() ([]byte, error) {
+	str := skipString(ib)
+	if str == "" {
+		return nil, fmt.Errorf("%d is not a supported InvalidBehavior constant", int(ib))
+	}
+	return json.Marshal(str)
+}
+
+// UnmarshalJSON parses a jCal SKIP string.
+func (ib *InvalidBehavior) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+
+	skip, err := parseSkip(str)
+	if err != nil {
+		return err
+	}
+	*ib = skip
+	return nil
+}
+
+// MarshalJSON returns the weekday as a jCal BYDAY string, such as "-1SU".
+func (wd QualifiedWeekday) MarshalJSON() ([]byte, error) {
+	if weekdayString(wd.WD) == "" {
+		return nil, fmt.Errorf("%d is not a valid weekday", int(wd.WD))
+	}
+	return json.Marshal(qualifiedWeekdayString(wd))
+}
+
+// UnmarshalJSON parses a jCal BYDAY string.
+func (wd *QualifiedWeekday) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	if strings.Contains(str, ",") {
+		return fmt.Errorf("%q is more than one weekday", str)
+	}
+
+	wds, err := parseQualifiedWeekdays(str)
+	if err != nil {
+		return err
+	}
+	*wd = wds[0]
+	return nil
+}
+
+// MarshalJSON returns the RRule as a jCal recur value, as described in section
+// 3.6.10 of RFC 7265, such as {"freq":"WEEKLY","byday":["MO","WE"]}. The parts
+// are those of String, in the same order. A part with several values is an
+// array, and UNTIL is a jCal DATE or DATE-TIME.
+func (rrule RRule) MarshalJSON() ([]byte, error) {
+	if _, err := freqToStr(rrule.Frequency); err != nil {
+		return nil, err
+	}
+	return jcalRecur(rrule.String())
+}
+
+// UnmarshalJSON parses a jCal recur value. Each part may be a single value or
+// an array of them. The RRule is validated as by ParseRRule.
+//
+// A JSON string is parsed as UnmarshalText does, and null is the zero RRule.
+func (rrule *RRule) UnmarshalJSON(data []byte) error {
+	if text, ok, err := jsonText(data); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		return rrule.UnmarshalText(text)
+	}
+
+	str, err := recurFromJCal(data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseRRule(str)
+	if err != nil {
+		return err
+	}
+	*rrule = parsed
+	return nil
+}
+
+// MarshalJSON returns the recurrence as an array of jCal properties, as
+// described in section 3.4 of RFC 7265, such as
+//
+//	[["dtstart",{"tzid":"America/New_York"},"date-time","2024-01-01T09:00:00"],
+//	 ["rrule",{},"recur",{"freq":"WEEKLY","count":5}]]
+//
+// The properties are those of String, so that the values of RDATE and EXDATE
+// are grouped the same way.
+func (r Recurrence) MarshalJSON() ([]byte, error) {
+	lines, err := parseContentLines([]byte(r.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	props := []json.RawMessage{}
+	for _, line := range lines {
+		prop, err := jcalProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		props = append(props, prop)
+	}
+	return json.Marshal(props)
+}
+
+// UnmarshalJSON parses an array of jCal properties, like ParseRecurrence does
+// their iCalendar form with a nil location. Properties other than DTSTART,
+// RRULE, EXRULE, RDATE, and EXDATE are ignored. TZIDs are loaded with
+// LoadLocation.
+//
+// A JSON string is parsed as UnmarshalText does, and null is the zero
+// Recurrence.
+func (r *Recurrence) UnmarshalJSON(data []byte) error {
+	if text, ok, err := jsonText(data); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		return r.UnmarshalText(text)
+	}
+
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+
+	var lines []contentLine
+	for _, prop := range props {
+		line, ok, err := jcalLine(prop)
+		if err != nil {
+			return err
+		}
+		if ok {
+			lines = append(lines, line)
+		}
+	}
+
+	parsed, err := parseRecurrenceLines(lines, nil, nil)
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// jsonText returns the text of data if it is a JSON string, or empty text if it
+// is null. ok is false for other JSON values.
+func jsonText(data []byte) (text []byte, ok bool, err error) {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil, true, nil
+	}
+	if len(data) == 0 || data[0] != '"' {
+		return nil, false, nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return nil, false, err
+	}
+	return []byte(str), true, nil
+}
+
+// recurPart is a part of an RRULE value, with UNTIL in the form that jCal and
+// xCal share.
+type recurPart struct {
+	name   string
+	values []string
+}
+
+// splitRecur splits the value of an RRULE into its parts.
+func splitRecur(str string) ([]recurPart, error) {
+	var parts []recurPart
+	for _, part := range strings.Split(str, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rrule segment %q is invalid", part)
+		}
+
+		p := recurPart{name: strings.ToUpper(name), values: strings.Split(value, ",")}
+		if p.name == "UNTIL" {
+			until, err := jcalTime(value)
+			if err != nil {
+				return nil, err
+			}
+			p.values = []string{until}
+		}
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
+
+// joinRecur joins parts into the value of an RRULE.
+func joinRecur(parts []recurPart) (string, error) {
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		values := p.values
+		if p.name == "UNTIL" {
+			values = make([]string, len(p.values))
+			for j, v := range p.values {
+				until, err := basicTime(v)
+				if err != nil {
+					return "", err
+				}
+				values[j] = until
+			}
+		}
+		strs[i] = p.name + "=" + strings.Join(values, ",")
+	}
+	return strings.Join(strs, ";"), nil
+}
+
+// jcalRecur converts the value of an RRULE to a jCal recur object.
+func jcalRecur(str string) ([]byte, error) {
+	parts, err := splitRecur(str)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteByte('{')
+
+	for i, p := range parts {
+		values := make([]any, len(p.values))
+		for j, v := range p.values {
+			values[j] = v
+			if recurIntParts[p.name] {
+				n, err := strconv.Atoi(v)
+				if err != nil {
+					return nil, err
+				}
+				values[j] = n
+			}
+		}
+
+		key, err := json.Marshal(strings.ToLower(p.name))
+		if err != nil {
+			return nil, err
+		}
+
+		var val []byte
+		if len(values) == 1 {
+			val, err = json.Marshal(values[0])
+		} else {
+			val, err = json.Marshal(values)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(val)
+	}
+
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// recurFromJCal converts a jCal recur object to the value of an RRULE. Parts
+// are written in alphabetical order.
+func recurFromJCal(data []byte) (string, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]recurPart, len(names))
+	for i, name := range names {
+		values, err := jcalValues(members[name])
+		if err != nil {
+			return "", fmt.Errorf("recur part %q: %v", name, err)
+		}
+
+		p := recurPart{name: strings.ToUpper(name), values: make([]string, len(values))}
+		for j, v := range values {
+			switch v := v.(type) {
+			case float64:
+				if v != math.Trunc(v) {
+					return "", fmt.Errorf("recur part %q: %v is not an integer", name, v)
+				}
+				p.values[j] = strconv.Itoa(int(v))
+			case string:
+				p.values[j] = v
+			default:
+				return "", fmt.Errorf("recur part %q: %v is not a string or number", name, v)
+			}
+		}
+		parts[i] = p
+	}
+
+	return joinRecur(parts)
+}
+
+// jcalValues decodes a jCal value that is either a single value or an array of
+// them.
+func jcalValues(data json.RawMessage) ([]any, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if values, ok := v.([]any); ok {
+		if len(values) == 0 {
+			return nil, errors.New("empty array")
+		}
+		return values, nil
+	}
+	return []any{v}, nil
+}
+
+// jcalProperty converts an iCalendar property to a jCal property array. The
+// VALUE parameter becomes the type of the property, and DATE, DATE-TIME, and
+// PERIOD values are converted to the jCal forms.
+func jcalProperty(line contentLine) (json.RawMessage, error) {
+	params := map[string]any{}
+	valueType := ""
+	for _, p := range line.params {
+		switch {
+		case p.name == "VALUE":
+			valueType = strings.ToLower(p.values[0])
+		case len(p.values) == 1:
+			params[strings.ToLower(p.name)] = p.values[0]
+		default:
+			params[strings.ToLower(p.name)] = p.values
+		}
+	}
+
+	prop := []any{strings.ToLower(line.name), params}
+
+	if line.name == "RRULE" || line.name == "EXRULE" {
+		recur, err := jcalRecur(line.value)
+		if err != nil {
+			return nil, err
+		}
+		prop = append(prop, "recur", json.RawMessage(recur))
+		return json.Marshal(prop)
+	}
+
+	if valueType == "" {
+		valueType = "date-time"
+	}
+	prop = append(prop, valueType)
+
+	for _, v := range strings.Split(line.value, ",") {
+		if valueType != "period" {
+			t, err := jcalTime(v)
+			if err != nil {
+				return nil, err
+			}
+			prop = append(prop, t)
+			continue
+		}
+
+		start, end, err := jcalPeriod(v)
+		if err != nil {
+			return nil, err
+		}
+		prop = append(prop, []string{start, end})
+	}
+
+	return json.Marshal(prop)
+}
+
+// jcalLine converts a jCal property array to an iCalendar property. ok is false
+// for properties that a recurrence ignores.
+func jcalLine(prop []json.RawMessage) (line contentLine, ok bool, err error) {
+	if len(prop) < 4 {
+		return line, false, errors.New("jCal property must have a name, parameters, type, and value")
+	}
+
+	var name, valueType string
+	var params map[string]json.RawMessage
+	for _, field := range []struct {
+		raw json.RawMessage
+		v   any
+	}{{prop[0], &name}, {prop[1], &params}, {prop[2], &valueType}} {
+		if err := json.Unmarshal(field.raw, field.v); err != nil {
+			return line, false, err
+		}
+	}
+
+	line.name = strings.ToUpper(name)
+	if !isRecurrenceProperty(line.name) {
+		return line, false, nil
+	}
+
+	for pname, raw := range params {
+		values, err := jcalValues(raw)
+		if err != nil {
+			return line, false, fmt.Errorf("parameter %q: %v", pname, err)
+		}
+		p := contentParam{name: strings.ToUpper(pname)}
+		for _, v := range values {
+			str, isStr := v.(string)
+			if !isStr {
+				return line, false, fmt.Errorf("parameter %q: %v is not a string", pname, v)
+			}
+			p.values = append(p.values, str)
+		}
+		line.params = append(line.params, p)
+	}
+
+	valueType = strings.ToUpper(valueType)
+	if valueType == "RECUR" {
+		line.value, err = recurFromJCal(prop[3])
+		return line, true, err
+	}
+	line.params = append(line.params, contentParam{name: "VALUE", values: []string{valueType}})
+
+	values := make([]string, len(prop)-3)
+	for i, raw := range prop[3:] {
+		if valueType == "PERIOD" {
+			var period []string
+			if err := json.Unmarshal(raw, &period); err != nil {
+				return line, false, err
+			}
+			if len(period) != 2 {
+				return line, false, fmt.Errorf("period %s must have a start and an end or duration", raw)
+			}
+
+			if values[i], err = basicPeriod(period[0], period[1]); err != nil {
+				return line, false, err
+			}
+			continue
+		}
+
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return line, false, err
+		}
+		if values[i], err = basicTime(str); err != nil {
+			return line, false, err
+		}
+	}
+	line.value = strings.Join(values, ",")
+
+	return line, true, nil
+}
+
+// isRecurrenceProperty reports whether name is one of the properties that
+// make up a recurrence.
+func isRecurrenceProperty(name string) bool {
+	switch name {
+	case "DTSTART", "RRULE", "EXRULE", "RDATE", "EXDATE":
+		return true
+	}
+	return false
+}
+
+// jcalPeriod converts a PERIOD value to the start and the end or duration of
+// its jCal form.
+func jcalPeriod(str string) (start, end string, err error) {
+	start, end, _ = strings.Cut(str, "/")
+	if start, err = jcalTime(start); err != nil {
+		return "", "", err
+	}
+	if !strings.HasPrefix(end, "P") {
+		if end, err = jcalTime(end); err != nil {
+			return "", "", err
+		}
+	}
+	return start, end, nil
+}
+
+// basicPeriod converts the start and the end or duration of a jCal period to
+// its iCalendar form.
+func basicPeriod(start, end string) (string, error) {
+	start, err := basicTime(start)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(end, "P") {
+		if end, err = basicTime(end); err != nil {
+			return "", err
+		}
+	}
+	return start + "/" + end, nil
+}
+
+// jcalTime converts a DATE or DATE-TIME value to its jCal form. A time with an
+// offset, which an UNTIL may have, is written in UTC.
+func jcalTime(str string) (string, error) {
+	if len(str) == len(rfc5545Date) {
+		t, err := parseDateValue(str, nil)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(jcalDate), nil
+	}
+
+	t, floating, err := parseTimeValue(str, nil, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	if floating {
+		return t.Format(jcalDateTime), nil
+	}
+	return t.UTC().Format(jcalDateTime) + "Z", nil
+}
+
+// basicTime converts a jCal DATE or DATE-TIME value to its iCalendar form.
+func basicTime(str string) (string, error) {
+	m := jcalTimeRegex.FindStringSubmatch(str)
+	if m == nil {
+		return "", fmt.Errorf("%q is not a jCal date or date-time", str)
+	}
+
+	if m[4] == "" {
+		return m[1] + m[2] + m[3], nil
+	}
+	return m[1] + m[2] + m[3] + "T" + m[4] + m[5] + m[6] + m[7], nil
+}
@@ -0,0 +1,211 @@
+package rrule
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRRuleJSON(t *testing.T) {
+	cases := []struct {
+		RRule string
+		JSON  string
+	}{
+		{
+			RRule: "FREQ=WEEKLY;BYDAY=MO,WE",
+			JSON:  `{"freq":"WEEKLY","byday":["MO","WE"]}`,
+		},
+		{
+			RRule: "FREQ=DAILY;UNTIL=19971224T000000Z;INTERVAL=2",
+			JSON:  `{"freq":"DAILY","until":"1997-12-24T00:00:00Z","interval":2}`,
+		},
+		{
+			RRule: "FREQ=DAILY;UNTIL=19971224T090000",
+			JSON:  `{"freq":"DAILY","until":"1997-12-24T09:00:00"}`,
+		},
+		{
+			RRule: "FREQ=DAILY;UNTIL=19971224",
+			JSON:  `{"freq":"DAILY","until":"1997-12-24"}`,
+		},
+		{
+			RRule: "FREQ=MONTHLY;COUNT=10;BYDAY=-1FR;BYMONTHDAY=13,-13;BYSETPOS=-1",
+			JSON:  `{"freq":"MONTHLY","count":10,"byday":"-1FR","bymonthday":[13,-13],"bysetpos":-1}`,
+		},
+		{
+			RRule: "FREQ=YEARLY;BYSECOND=0;BYMINUTE=30;BYHOUR=9,17;BYWEEKNO=20;BYYEARDAY=1,100;BYMONTH=1,2;WKST=SU",
+			JSON:  `{"freq":"YEARLY","bysecond":0,"byminute":30,"byhour":[9,17],"byweekno":20,"byyearday":[1,100],"bymonth":[1,2],"wkst":"SU"}`,
+		},
+		{
+			RRule: "FREQ=MONTHLY;BYMONTHDAY=31;SKIP=BACKWARD;RSCALE=GREGORIAN",
+			JSON:  `{"freq":"MONTHLY","bymonthday":31,"skip":"BACKWARD","rscale":"GREGORIAN"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.RRule, func(t *testing.T) {
+			rrule, err := ParseRRule(tc.RRule)
+			require.NoError(t, err)
+
+			b, err := json.Marshal(rrule)
+			require.NoError(t, err)
+			assert.Equal(t, tc.JSON, string(b))
+
+			var decoded RRule
+			require.NoError(t, json.Unmarshal(b, &decoded))
+			assert.Equal(t, tc.RRule, decoded.String())
+			assert.True(t, rrule.Until.Equal(decoded.Until))
+		})
+	}
+}
+
+func TestRRuleJSONUntilOffset(t *testing.T) {
+	rrule := RRule{Frequency: Daily, Until: time.Date(1997, 12, 24, 9, 0, 0, 0, NewYork())}
+
+	b, err := json.Marshal(rrule)
+	require.NoError(t, err)
+	assert.Equal(t, `{"freq":"DAILY","until":"1997-12-24T14:00:00Z"}`, string(b))
+}
+
+func TestRRuleJSONUnmarshal(t *testing.T) {
+	var rrule RRule
+	require.NoError(t, json.Unmarshal([]byte(`{"freq":"yearly","byday":["-1SU"],"bymonth":[3],"count":2}`), &rrule))
+	assert.Equal(t, "FREQ=YEARLY;COUNT=2;BYDAY=-1SU;BYMONTH=3", rrule.String())
+
+	for _, src := range []string{
+		`[]`,
+		`{"freq":"FORTNIGHTLY"}`,
+		`{"freq":"DAILY","count":1.5}`,
+		`{"freq":"DAILY","count":2,"until":"1997-12-24"}`,
+		`{"freq":"DAILY","until":"19971224"}`,
+		`{"freq":"DAILY","byday":[]}`,
+		`{"freq":"DAILY","byday":[{}]}`,
+		`{"freq":"DAILY","bogus":1}`,
+		`{"freq":"DAILY","interval":0}`,
+	} {
+		assert.Error(t, json.Unmarshal([]byte(src), &rrule), src)
+	}
+}
+
+func TestEnumJSON(t *testing.T) {
+	b, err := json.Marshal([]any{Weekly, NextInvalid, QualifiedWeekday{N: -2, WD: time.Tuesday}})
+	require.NoError(t, err)
+	assert.Equal(t, `["WEEKLY","FORWARD","-2TU"]`, string(b))
+
+	var freq Frequency
+	require.NoError(t, json.Unmarshal([]byte(`"HOURLY"`), &freq))
+	assert.Equal(t, Hourly, freq)
+
+	var ib InvalidBehavior
+	require.NoError(t, json.Unmarshal([]byte(`"BACKWARD"`), &ib))
+	assert.Equal(t, PrevInvalid, ib)
+
+	var wd QualifiedWeekday
+	require.NoError(t, json.Unmarshal([]byte(`"3FR"`), &wd))
+	assert.Equal(t, QualifiedWeekday{N: 3, WD: time.Friday}, wd)
+
+	for _, v := range []any{Frequency(99), InvalidBehavior(99), QualifiedWeekday{WD: 9}} {
+		_, err := json.Marshal(v)
+		assert.Error(t, err, "%v", v)
+	}
+
+	assert.Error(t, json.Unmarshal([]byte(`"FORTNIGHTLY"`), &freq))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &freq))
+	assert.Error(t, json.Unmarshal([]byte(`"SIDEWAYS"`), &ib))
+	assert.Error(t, json.Unmarshal([]byte(`"MO,TU"`), &wd))
+	assert.Error(t, json.Unmarshal([]byte(`"XX"`), &wd))
+}
+
+func TestRecurrenceJSON(t *testing.T) {
+	src := "DTSTART;TZID=Europe/Berlin:20240101T090000\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=5\n" +
+		"EXRULE:FREQ=MONTHLY;BYMONTHDAY=15\n" +
+		"RDATE:20240103T000000Z,20240112T120000Z\n" +
+		"RDATE;VALUE=PERIOD;TZID=Europe/Berlin:20240110T140000/20240110T153000,20240111T140000/PT1H\n" +
+		"EXDATE;TZID=Europe/Berlin:20240108T090000,20240122T090000\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		["dtstart",{"tzid":"Europe/Berlin"},"date-time","2024-01-01T09:00:00"],
+		["rrule",{},"recur",{"freq":"WEEKLY","count":5}],
+		["exrule",{},"recur",{"freq":"MONTHLY","bymonthday":15}],
+		["rdate",{},"date-time","2024-01-03T00:00:00Z","2024-01-12T12:00:00Z"],
+		["rdate",{"tzid":"Europe/Berlin"},"period",
+			["2024-01-10T14:00:00","2024-01-10T15:30:00"],
+			["2024-01-11T14:00:00","PT1H"]],
+		["exdate",{"tzid":"Europe/Berlin"},"date-time","2024-01-08T09:00:00","2024-01-22T09:00:00"]
+	]`, string(b))
+
+	var decoded Recurrence
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, src, decoded.String())
+	assert.Equal(t, rfcAll(All(r.Iterator(), 0)), rfcAll(All(decoded.Iterator(), 0)))
+}
+
+func TestRecurrenceJSONAllDayAndFloating(t *testing.T) {
+	for _, src := range []string{
+		"DTSTART;VALUE=DATE:20240101\n" +
+			"RRULE:FREQ=YEARLY;UNTIL=20260101\n" +
+			"RDATE;VALUE=DATE:20240704\n",
+		"DTSTART:20240101T090000\n" +
+			"RRULE:FREQ=DAILY;COUNT=3\n" +
+			"EXDATE:20240102T090000\n",
+	} {
+		r, err := ParseRecurrence([]byte(src), nil)
+		require.NoError(t, err)
+
+		b, err := json.Marshal(r)
+		require.NoError(t, err)
+
+		var decoded Recurrence
+		require.NoError(t, json.Unmarshal(b, &decoded), string(b))
+		assert.Equal(t, src, decoded.String(), string(b))
+		assert.Equal(t, r.AllDay, decoded.AllDay)
+		assert.Equal(t, r.FloatingLocation, decoded.FloatingLocation)
+	}
+
+	r, err := ParseRecurrence([]byte("DTSTART;VALUE=DATE:20240101\n"), nil)
+	require.NoError(t, err)
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	assert.Equal(t, `[["dtstart",{},"date","2024-01-01"]]`, string(b))
+
+	b, err = json.Marshal(Recurrence{})
+	require.NoError(t, err)
+	assert.Equal(t, `[]`, string(b))
+}
+
+func TestRecurrenceJSONUnmarshal(t *testing.T) {
+	var r Recurrence
+	require.NoError(t, json.Unmarshal([]byte(`[
+		["uid",{},"text","abc"],
+		["dtstart",{"tzid":"America/New_York"},"date-time","2024-01-01T09:00:00"],
+		["rrule",{},"recur",{"freq":"DAILY","count":2}]
+	]`), &r))
+	assert.Equal(t, []string{
+		"2024-01-01T09:00:00-05:00",
+		"2024-01-02T09:00:00-05:00",
+	}, rfcAll(All(r.Iterator(), 0)))
+
+	for _, src := range []string{
+		`{}`,
+		`[["dtstart",{},"date-time"]]`,
+		`[["dtstart",[],"date-time","2024-01-01T09:00:00Z"]]`,
+		`[["dtstart",{},"date-time","20240101T090000Z"]]`,
+		`[["dtstart",{"tzid":"Nowhere/Nothing"},"date-time","2024-01-01T09:00:00"]]`,
+		`[["dtstart",{"tzid":1},"date-time","2024-01-01T09:00:00"]]`,
+		`[["rrule",{},"recur",{"freq":"NEVER"}]]`,
+		`[["rdate",{},"period",["2024-01-01T09:00:00Z"]]]`,
+		`[["rdate",{},"period",["2024-01-01T09:00:00Z","2024-01-01"]]]`,
+		`[["exdate",{},"period",["2024-01-01T09:00:00Z","PT1H"]]]`,
+		`[["rdate",{},"binary","AAAA"]]`,
+	} {
+		assert.Error(t, json.Unmarshal([]byte(src), &r), src)
+	}
+}
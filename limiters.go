@@ -1,70 +1,99 @@
 package rrule
 
 import (
-	"sort"
+	"slices"
 	"time"
 )
 
+// limitBySetPos keeps the times of tt at the positions of setpos, in
+// chronological order. tt is filtered in place.
 func limitBySetPos(tt []time.Time, setpos []int) []time.Time {
 	if len(setpos) == 0 {
 		return tt
 	}
 
-	// a map of tt indexes to include
-	include := map[int]bool{}
-
-	for _, sp := range setpos {
-		if sp < 0 {
-			sp = len(tt) + sp
-		} else {
-			sp-- // setpos is 1-indexed in the rrule. adjust here
+	ret := tt[:0]
+	for idx, t := range tt {
+		if atSetPos(idx, len(tt), setpos) {
+			ret = append(ret, t)
 		}
-
-		include[sp] = true
 	}
 
-	ret := make([]time.Time, 0, len(include))
-	for included := range include {
-		if len(tt) > included {
-			ret = append(ret, tt[included])
-		}
-	}
-
-	sort.Slice(ret, func(i, j int) bool {
-		return ret[i].Before(ret[j])
-	})
+	slices.SortFunc(ret, time.Time.Compare)
 
 	return ret
 }
 
-func limitInstancesBySetPos(tt []int, setpos []int) []int {
-	if len(setpos) == 0 {
-		return tt
-	}
-
-	// a map of tt indexes to include
-	include := make(map[int]bool, len(setpos))
-
+// atSetPos reports whether the zero-based index idx of a set of n items is
+// one of the positions of setpos.
+func atSetPos(idx, n int, setpos []int) bool {
 	for _, sp := range setpos {
 		if sp < 0 {
-			sp = len(tt) + sp
+			sp = n + sp
 		} else {
 			sp-- // setpos is 1-indexed in the rrule. adjust here
 		}
 
-		include[sp] = true
+		if sp == idx {
+			return true
+		}
 	}
+	return false
+}
 
-	ret := make([]int, 0, len(include))
-	for included := range include {
-		if len(tt) > included {
-			ret = append(ret, tt[included])
-		}
+// maxPeriodSize returns the most wall-clock times a period of the pattern can
+// have, or more. Each BYxxx part that expands the period multiplies them by
+// its number of values, or at most the number of days of the period.
+func (rrule RRule) maxPeriodSize() int {
+	values := func(n int) int {
+		return max(n, 1)
 	}
 
-	sort.Ints(ret)
+	size := 1
+	if rrule.Frequency > Secondly {
+		size *= values(len(rrule.BySeconds))
+	}
+	if rrule.Frequency > Minutely {
+		size *= values(len(rrule.ByMinutes))
+	}
+	if rrule.Frequency > Hourly {
+		size *= values(len(rrule.ByHours))
+	}
 
-	return ret
+	switch rrule.Frequency {
+	case Weekly:
+		size *= min(values(len(rrule.ByWeekdays)), 7)
+	case Monthly:
+		days := len(rrule.ByMonthDays)
+		if days == 0 {
+			// a weekday without a number is up to five days of the month.
+			for _, wd := range rrule.ByWeekdays {
+				days++
+				if wd.N == 0 {
+					days += 4
+				}
+			}
+		}
+		size *= min(values(days), 31)
+	case Yearly:
+		if len(rrule.ByYearDays) > 0 ||
+			len(rrule.ByWeekNumbers) > 0 ||
+			len(rrule.ByMonthDays) > 0 ||
+			len(rrule.ByWeekdays) > 0 {
+			size *= 366
+		} else {
+			size *= values(len(rrule.ByMonths))
+		}
+	}
+	return size
+}
+
+// selectsFrom reports whether any position of setpos is within a set of n
+// items.
+func selectsFrom(setpos []int, n int) bool {
+	return slices.ContainsFunc(setpos, func(sp int) bool {
+		return sp <= n && -sp <= n
+	})
 }
 
 func combineLimiters(ll ...validFunc) func(t *time.Time) bool {
@@ -0,0 +1,132 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBySetPosMatrix checks that BYSETPOS selects among every occurrence of a
+// period, for each frequency and the rule parts that expand or limit it. The
+// expected occurrences are those of the same rule without BYSETPOS, grouped by
+// period and selected from directly.
+func TestBySetPosMatrix(t *testing.T) {
+	// the pattern starts and ends on the bounds of its periods, so that none
+	// of its periods are cut short, and is followed long enough to visit
+	// several periods without visiting so many seconds that the matrix is slow.
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ends := map[Frequency]time.Time{
+		Secondly: start.Add(time.Hour),
+		Minutely: start.AddDate(0, 0, 1),
+		Hourly:   start.AddDate(0, 0, 30),
+		Daily:    start.AddDate(1, 0, 0),
+		Weekly:   start.AddDate(0, 0, 2*52*7),
+		Monthly:  start.AddDate(5, 0, 0),
+		Yearly:   start.AddDate(20, 0, 0),
+	}
+
+	parts := []string{
+		"BYSECOND=10,20,30",
+		"BYMINUTE=5,10;BYSECOND=0,30",
+		"BYHOUR=9,17",
+		"BYHOUR=9,17;BYMINUTE=0,30",
+		"BYDAY=MO,WE,FR",
+		"BYDAY=MO,FR;BYHOUR=9,17",
+		"BYDAY=MO,FR;WKST=SU",
+		"BYMONTHDAY=1,15,-1",
+		"BYMONTHDAY=1,15;BYHOUR=9,12",
+		"BYMONTHDAY=1,2,3,4,5,6,7;BYDAY=MO,TU",
+		"BYMONTH=1,6",
+		"BYMONTH=1,6;BYDAY=MO",
+		"BYMONTH=1,6;BYDAY=1MO,-1FR",
+		"BYMONTH=1,6;BYMONTHDAY=1,-1",
+		"BYYEARDAY=1,100,-1",
+		"BYWEEKNO=1,20",
+		"BYWEEKNO=1,20;BYDAY=MO,FR",
+	}
+
+	for _, freq := range []string{"SECONDLY", "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY"} {
+		for _, part := range parts {
+			for _, setpos := range []string{"1", "-1", "2", "2,-1", "1,-2", "1,3,-1", "-3,1"} {
+				str := "FREQ=" + freq + ";" + part + ";BYSETPOS=" + setpos
+
+				// not every part is allowed with every frequency.
+				r, err := ParseRRule(str)
+				if err != nil || r.Validate() != nil {
+					continue
+				}
+
+				t.Run(str, func(t *testing.T) {
+					r.Dtstart = start
+					r.Until = ends[r.Frequency].Add(-time.Second)
+
+					unlimited := r
+					unlimited.BySetPos = nil
+					expected := selectBySetPos(All(unlimited.Iterator(), 0), r)
+
+					assert.Equal(t, rfcAll(expected), rfcAll(All(r.Iterator(), 0)))
+
+					// with COUNT instead of UNTIL, the first occurrences are
+					// the same, and a pattern whose BYSETPOS never selects
+					// anything ends too.
+					counted := r
+					counted.Until = time.Time{}
+					counted.Count = 3
+					got := All(counted.Iterator(), 0)
+					if len(expected) < len(got) {
+						got = got[:len(expected)]
+					}
+					assert.Equal(t, rfcAll(expected[:min(len(expected), 3)]), rfcAll(got))
+				})
+			}
+		}
+	}
+}
+
+// selectBySetPos groups times, which are in order, by the period of rrule they
+// fall in, and keeps those at the positions of its BYSETPOS in each.
+func selectBySetPos(times []time.Time, rrule RRule) []time.Time {
+	period := func(t time.Time) time.Time {
+		switch rrule.Frequency {
+		case Yearly:
+			// the years of BYWEEKNO are those its weeks are numbered in.
+			if len(rrule.ByWeekNumbers) > 0 {
+				year, _, _ := weekNumber(t, rrule.weekStart())
+				return time.Date(year, time.January, 1, 0, 0, 0, 0, t.Location())
+			}
+			return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+		case Monthly:
+			return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		case Weekly:
+			return backToWeekday(t.Truncate(24*time.Hour), rrule.weekStart())
+		case Daily:
+			return t.Truncate(24 * time.Hour)
+		case Hourly:
+			return t.Truncate(time.Hour)
+		case Minutely:
+			return t.Truncate(time.Minute)
+		}
+		return t.Truncate(time.Second)
+	}
+
+	var selected []time.Time
+	for len(times) > 0 {
+		n := 1
+		for n < len(times) && period(times[n]).Equal(period(times[0])) {
+			n++
+		}
+
+		group := times[:n]
+		for i := range group {
+			for _, pos := range rrule.BySetPos {
+				if pos == i+1 || pos == i-len(group) {
+					selected = append(selected, group[i])
+					break
+				}
+			}
+		}
+		times = times[n:]
+	}
+	return selected
+}
@@ -0,0 +1,226 @@
+package rrule
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Normalize returns the canonical form of the pattern, which has the same
+// occurrences. The values of each BYxxx part are sorted and duplicates are
+// removed, BYDAY from Monday to Sunday. Parts left at their defaults, such as
+// INTERVAL=1 or WKST=MO, are dropped, as is a WKST that the pattern does not
+// depend on. A non-floating Until that is not a date is moved to UTC.
+//
+// If Dtstart is set, parts that only repeat what it implies are dropped too,
+// such as a BYMONTH of Dtstart's month on a YEARLY pattern without other BYxxx
+// parts that choose days, or a BYHOUR of Dtstart's hour on a DAILY pattern.
+// Nothing is dropped from a pattern with BYSETPOS, since it selects among the
+// occurrences of each period.
+func (rrule RRule) Normalize() RRule {
+	if rrule.Interval == 1 {
+		rrule.Interval = 0
+	}
+
+	if !rrule.Until.IsZero() && !rrule.UntilFloating && !rrule.UntilDate {
+		rrule.Until = rrule.Until.UTC()
+	}
+
+	rrule.BySeconds = sortedInts(rrule.BySeconds)
+	rrule.ByMinutes = sortedInts(rrule.ByMinutes)
+	rrule.ByHours = sortedInts(rrule.ByHours)
+	rrule.ByMonthDays = sortedInts(rrule.ByMonthDays)
+	rrule.ByWeekNumbers = sortedInts(rrule.ByWeekNumbers)
+	rrule.ByYearDays = sortedInts(rrule.ByYearDays)
+	rrule.BySetPos = sortedInts(rrule.BySetPos)
+	rrule.ByMonths = sortedSet(rrule.ByMonths, cmp.Compare[time.Month])
+	rrule.ByWeekdays = sortedSet(rrule.ByWeekdays, func(a, b QualifiedWeekday) int {
+		if c := cmp.Compare((a.WD+6)%7, (b.WD+6)%7); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.N, b.N)
+	})
+
+	if !rrule.Dtstart.IsZero() && len(rrule.BySetPos) == 0 {
+		rrule.dropImplied()
+	}
+
+	if rrule.WeekStart != nil && (*rrule.WeekStart == time.Monday || !rrule.dependsOnWeekStart()) {
+		rrule.WeekStart = nil
+	}
+
+	return rrule
+}
+
+// dropImplied drops the parts that only select the occurrences Dtstart implies
+// without them.
+func (rrule *RRule) dropImplied() {
+	implied := func(values []int, freq Frequency, value int) []int {
+		if rrule.Frequency >= freq && len(values) == 1 && values[0] == value {
+			return nil
+		}
+		return values
+	}
+	rrule.BySeconds = implied(rrule.BySeconds, Minutely, rrule.Dtstart.Second())
+	rrule.ByMinutes = implied(rrule.ByMinutes, Hourly, rrule.Dtstart.Minute())
+	rrule.ByHours = implied(rrule.ByHours, Daily, rrule.Dtstart.Hour())
+
+	if rrule.Frequency == Weekly &&
+		len(rrule.ByWeekdays) == 1 &&
+		rrule.ByWeekdays[0] == (QualifiedWeekday{WD: rrule.Dtstart.Weekday()}) {
+		rrule.ByWeekdays = nil
+	}
+
+	if rrule.Frequency == Yearly &&
+		len(rrule.ByMonths) == 1 &&
+		rrule.ByMonths[0] == rrule.Dtstart.Month() &&
+		len(rrule.ByWeekNumbers) == 0 &&
+		len(rrule.ByYearDays) == 0 &&
+		len(rrule.ByMonthDays) == 0 &&
+		len(rrule.ByWeekdays) == 0 {
+		rrule.ByMonths = nil
+	}
+}
+
+// dependsOnWeekStart reports whether the occurrences of the pattern depend on
+// which day weeks start, which is only the case for a WEEKLY pattern with
+// BYDAY that skips weeks or uses BYSETPOS, or a pattern with BYWEEKNO.
+func (rrule RRule) dependsOnWeekStart() bool {
+	if len(rrule.ByWeekNumbers) > 0 {
+		return true
+	}
+	return rrule.Frequency == Weekly &&
+		len(rrule.ByWeekdays) > 0 &&
+		(rrule.Interval > 1 || len(rrule.BySetPos) > 0)
+}
+
+// Equal reports whether the normalized forms of the patterns are the same,
+// including their Dtstart, which must be the same instant in the same location.
+// Patterns that are Equal have the same occurrences.
+func (rrule RRule) Equal(other RRule) bool {
+	a, b := rrule.Normalize(), other.Normalize()
+
+	return a.Frequency == b.Frequency &&
+		sameStart(a.Dtstart, b.Dtstart) &&
+		a.Until.Equal(b.Until) &&
+		a.UntilFloating == b.UntilFloating &&
+		a.UntilDate == b.UntilDate &&
+		a.Count == b.Count &&
+		a.Interval == b.Interval &&
+		slices.Equal(a.BySeconds, b.BySeconds) &&
+		slices.Equal(a.ByMinutes, b.ByMinutes) &&
+		slices.Equal(a.ByHours, b.ByHours) &&
+		slices.Equal(a.ByWeekdays, b.ByWeekdays) &&
+		slices.Equal(a.ByMonthDays, b.ByMonthDays) &&
+		slices.Equal(a.ByWeekNumbers, b.ByWeekNumbers) &&
+		slices.Equal(a.ByMonths, b.ByMonths) &&
+		slices.Equal(a.ByYearDays, b.ByYearDays) &&
+		slices.Equal(a.BySetPos, b.BySetPos) &&
+		a.InvalidBehavior == b.InvalidBehavior &&
+		a.NonexistentBehavior == b.NonexistentBehavior &&
+		a.ambiguousBehavior() == b.ambiguousBehavior() &&
+		(a.WeekStart == nil) == (b.WeekStart == nil) &&
+		(a.WeekStart == nil || *a.WeekStart == *b.WeekStart)
+}
+
+// Equal reports whether the recurrences are the same once normalized. Their
+// Dtstart must be the same instant in the same location, and the patterns of
+// each are compared with RRule.Equal, taking Dtstart from the recurrence,
+// regardless of their order or repetition. Dates and periods are compared as
+// sets of instants, so their order, repetition, and locations do not matter.
+//
+// FloatingLocation and AllDay must also be the same. The times of an all-day
+// recurrence are compared as dates, as its iterators use them.
+func (r Recurrence) Equal(other Recurrence) bool {
+	a, b := r.normalized(), other.normalized()
+
+	return sameStart(a.Dtstart, b.Dtstart) &&
+		a.FloatingLocation == b.FloatingLocation &&
+		a.AllDay == b.AllDay &&
+		sameRules(a.RRules, b.RRules) &&
+		sameRules(a.ExRules, b.ExRules) &&
+		slices.EqualFunc(instants(a.RDates), instants(b.RDates), time.Time.Equal) &&
+		slices.EqualFunc(instants(a.ExDates), instants(b.ExDates), time.Time.Equal) &&
+		slices.EqualFunc(periodSet(a.RPeriods), periodSet(b.RPeriods), func(a, b Period) bool {
+			return a.Start.Equal(b.Start) && a.End.Equal(b.End)
+		})
+}
+
+// normalized returns a copy of r with Dtstart set on its patterns, and with an
+// all-day recurrence anchored to its dates, without modifying r.
+func (r Recurrence) normalized() Recurrence {
+	r.RRules = slices.Clone(r.RRules)
+	r.ExRules = slices.Clone(r.ExRules)
+
+	// an all-day recurrence without a Dtstart is compared as it is.
+	_ = r.setDtstart()
+	return r
+}
+
+// sameStart reports whether a and b are the same instant in the same location,
+// or both zero.
+func sameStart(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() && b.IsZero()
+	}
+	return a.Equal(b) && a.Location().String() == b.Location().String()
+}
+
+// sameRules reports whether every pattern of a is Equal to one of b, and the
+// other way around.
+func sameRules(a, b []RRule) bool {
+	contains := func(rules []RRule, rule RRule) bool {
+		return slices.ContainsFunc(rules, rule.Equal)
+	}
+	for _, rule := range a {
+		if !contains(b, rule) {
+			return false
+		}
+	}
+	for _, rule := range b {
+		if !contains(a, rule) {
+			return false
+		}
+	}
+	return true
+}
+
+// instants returns tt sorted, without repeated instants.
+func instants(tt []time.Time) []time.Time {
+	return sortedSet(tt, time.Time.Compare)
+}
+
+// periodSet returns periods sorted, without repetition, and with End set from
+// Duration where it is set.
+func periodSet(periods []Period) []Period {
+	resolved := make([]Period, len(periods))
+	for i, p := range periods {
+		if p.Duration != 0 {
+			p.End = addDuration(p.Start, p.Duration)
+		}
+		resolved[i] = Period{Start: p.Start, End: p.End}
+	}
+	return sortedSet(resolved, func(a, b Period) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return a.End.Compare(b.End)
+	})
+}
+
+// sortedInts returns ints sorted and without duplicates, or nil if it is empty.
+func sortedInts(ints []int) []int {
+	return sortedSet(ints, cmp.Compare[int])
+}
+
+// sortedSet returns a sorted copy of values without elements that compare
+// equal, or nil if it is empty.
+func sortedSet[T any](values []T, compare func(a, b T) int) []T {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.SortedFunc(slices.Values(values), compare)
+	return slices.CompactFunc(sorted, func(a, b T) bool {
+		return compare(a, b) == 0
+	})
+}
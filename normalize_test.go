@@ -0,0 +1,197 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	dtstart := time.Date(2024, 3, 4, 9, 30, 0, 0, NewYork()) // a Monday
+
+	for input, want := range map[string]string{
+		"FREQ=WEEKLY;BYDAY=TU,MO,TU,SU":                  "FREQ=WEEKLY;BYDAY=MO,TU,SU",
+		"FREQ=MONTHLY;BYDAY=-1FR,1MO,FR,1MO":             "FREQ=MONTHLY;BYDAY=1MO,-1FR,FR",
+		"FREQ=DAILY;INTERVAL=1;BYMONTH=6,1,6":            "FREQ=DAILY;BYMONTH=1,6",
+		"FREQ=DAILY;WKST=MO":                             "FREQ=DAILY",
+		"FREQ=DAILY;WKST=SU":                             "FREQ=DAILY",
+		"FREQ=WEEKLY;INTERVAL=2;WKST=SU":                 "FREQ=WEEKLY;INTERVAL=2",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU":     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU",
+		"FREQ=YEARLY;BYWEEKNO=20,1;WKST=SU":              "FREQ=YEARLY;BYWEEKNO=1,20;WKST=SU",
+		"FREQ=YEARLY;BYMONTH=3":                          "FREQ=YEARLY",
+		"FREQ=YEARLY;BYMONTH=3;BYDAY=MO":                 "FREQ=YEARLY;BYDAY=MO;BYMONTH=3",
+		"FREQ=YEARLY;BYMONTH=3,4":                        "FREQ=YEARLY;BYMONTH=3,4",
+		"FREQ=WEEKLY;BYDAY=MO":                           "FREQ=WEEKLY",
+		"FREQ=DAILY;BYHOUR=9;BYMINUTE=30;BYSECOND=0":     "FREQ=DAILY",
+		"FREQ=HOURLY;BYMINUTE=30;BYSECOND=0":             "FREQ=HOURLY",
+		"FREQ=HOURLY;BYHOUR=9":                           "FREQ=HOURLY;BYHOUR=9",
+		"FREQ=MINUTELY;BYMINUTE=30":                      "FREQ=MINUTELY;BYMINUTE=30",
+		"FREQ=MONTHLY;BYDAY=MO;BYHOUR=9;BYSETPOS=1":      "FREQ=MONTHLY;BYHOUR=9;BYDAY=MO;BYSETPOS=1",
+		"FREQ=DAILY;UNTIL=20240401T100000-0400":          "FREQ=DAILY;UNTIL=20240401T140000Z",
+		"FREQ=DAILY;UNTIL=20240401":                      "FREQ=DAILY;UNTIL=20240401",
+		"FREQ=YEARLY;BYSETPOS=-1,1,-1;BYMONTHDAY=2,1,31": "FREQ=YEARLY;BYMONTHDAY=1,2,31;BYSETPOS=-1,1",
+	} {
+		rrule, err := ParseRRule(input)
+		require.NoError(t, err, input)
+		rrule.Dtstart = dtstart
+
+		normalized := rrule.Normalize()
+		assert.Equal(t, want, normalized.String(), input)
+		assert.True(t, rrule.Equal(normalized), input)
+		assert.Equal(t, normalized, normalized.Normalize(), input)
+	}
+
+	// without Dtstart, nothing it implies can be dropped.
+	rrule, err := ParseRRule("FREQ=YEARLY;BYMONTH=3;BYHOUR=9")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=YEARLY;BYHOUR=9;BYMONTH=3", rrule.Normalize().String())
+
+	// slices of the original are not modified.
+	rrule = RRule{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: time.Friday}, {WD: time.Monday}}}
+	rrule.Normalize()
+	assert.Equal(t, []QualifiedWeekday{{WD: time.Friday}, {WD: time.Monday}}, rrule.ByWeekdays)
+}
+
+func TestNormalizeOccurrences(t *testing.T) {
+	sunday := time.Sunday
+
+	rules := []RRule{
+		{Frequency: Weekly, Interval: 2, WeekStart: &sunday, Count: 10, Dtstart: now},
+		{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: now.Weekday()}}, Count: 10, Dtstart: now},
+		{Frequency: Yearly, ByMonths: []time.Month{now.Month()}, Count: 5, Dtstart: now},
+		{Frequency: Daily, ByHours: []int{now.Hour()}, ByMinutes: []int{now.Minute()}, BySeconds: []int{now.Second()}, Count: 5, Dtstart: now},
+		{Frequency: Minutely, BySeconds: []int{now.Second()}, Count: 5, Dtstart: now},
+		{Frequency: Daily, WeekStart: &sunday, Count: 10, Dtstart: now},
+	}
+	for _, tc := range cases {
+		if !tc.NoTest {
+			rules = append(rules, tc.RRule)
+		}
+	}
+
+	for _, rrule := range rules {
+		normalized := rrule.Normalize()
+		assert.Equal(t,
+			rfcAll(All(rrule.Iterator(), 100)),
+			rfcAll(All(normalized.Iterator(), 100)),
+			"%s normalized to %s", rrule, normalized)
+	}
+}
+
+func TestRRuleEqual(t *testing.T) {
+	parse := func(str string, dtstart time.Time) RRule {
+		rrule, err := ParseRRule(str)
+		require.NoError(t, err, str)
+		rrule.Dtstart = dtstart
+		return rrule
+	}
+
+	dtstart := time.Date(2024, 3, 4, 9, 30, 0, 0, NewYork())
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	for _, pair := range [][2]string{
+		{"FREQ=WEEKLY;BYDAY=MO,TU", "FREQ=WEEKLY;BYDAY=TU,MO"},
+		{"FREQ=WEEKLY;INTERVAL=1", "FREQ=WEEKLY"},
+		{"FREQ=WEEKLY;BYDAY=MO,MO,TU", "FREQ=WEEKLY;BYDAY=TU,MO"},
+		{"FREQ=YEARLY;BYMONTH=3", "FREQ=YEARLY"},
+		{"FREQ=DAILY;UNTIL=20240401T100000-0400", "FREQ=DAILY;UNTIL=20240401T140000Z"},
+	} {
+		assert.True(t, parse(pair[0], dtstart).Equal(parse(pair[1], dtstart)), "%s == %s", pair[0], pair[1])
+	}
+
+	for _, pair := range [][2]string{
+		{"FREQ=WEEKLY;BYDAY=MO,TU", "FREQ=WEEKLY;BYDAY=MO"},
+		{"FREQ=WEEKLY;INTERVAL=2", "FREQ=WEEKLY"},
+		{"FREQ=YEARLY;BYMONTH=4", "FREQ=YEARLY"},
+		{"FREQ=DAILY;COUNT=2", "FREQ=DAILY"},
+		{"FREQ=DAILY;UNTIL=20240401", "FREQ=DAILY;UNTIL=20240401T000000Z"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU;WKST=SU", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,SU"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31;SKIP=FORWARD", "FREQ=MONTHLY;BYMONTHDAY=31"},
+	} {
+		assert.False(t, parse(pair[0], dtstart).Equal(parse(pair[1], dtstart)), "%s != %s", pair[0], pair[1])
+	}
+
+	// Dtstart must be the same instant in the same location.
+	assert.False(t, parse("FREQ=DAILY", dtstart).Equal(parse("FREQ=DAILY", dtstart.Add(time.Hour))))
+	assert.False(t, parse("FREQ=DAILY", dtstart).Equal(parse("FREQ=DAILY", dtstart.In(berlin))))
+	assert.False(t, parse("FREQ=DAILY", dtstart).Equal(parse("FREQ=DAILY", time.Time{})))
+	assert.True(t, parse("FREQ=DAILY", time.Time{}).Equal(parse("FREQ=DAILY;INTERVAL=1", time.Time{})))
+}
+
+func TestRecurrenceEqual(t *testing.T) {
+	parse := func(src string) Recurrence {
+		r, err := ParseRecurrence([]byte(src), nil)
+		require.NoError(t, err, src)
+		return *r
+	}
+
+	base := parse("DTSTART;TZID=America/New_York:20240304T093000\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+		"RRULE:FREQ=YEARLY;BYMONTH=3\n" +
+		"RDATE:20240310T140000Z,20240311T140000Z\n" +
+		"RDATE;VALUE=PERIOD:20240312T140000Z/PT1H\n" +
+		"EXDATE;TZID=America/New_York:20240306T093000\n")
+
+	for _, src := range []string{
+		"DTSTART;TZID=America/New_York:20240304T093000\n" +
+			"RRULE:FREQ=YEARLY\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=WE,MO;INTERVAL=1\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+			"RDATE;TZID=Europe/Berlin:20240311T150000,20240310T150000\n" +
+			"RDATE:20240311T140000Z\n" +
+			"RDATE;VALUE=PERIOD:20240312T140000Z/20240312T150000Z\n" +
+			"EXDATE:20240306T143000Z\n",
+	} {
+		assert.True(t, base.Equal(parse(src)), src)
+	}
+
+	for _, src := range []string{
+		"DTSTART;TZID=America/New_York:20240304T093000\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+			"RDATE:20240310T140000Z,20240311T140000Z\n" +
+			"RDATE;VALUE=PERIOD:20240312T140000Z/PT1H\n" +
+			"EXDATE;TZID=America/New_York:20240306T093000\n",
+		"DTSTART;TZID=America/New_York:20240304T093000\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+			"RRULE:FREQ=YEARLY;BYMONTH=3\n" +
+			"RDATE:20240310T140000Z,20240311T140000Z\n" +
+			"RDATE;VALUE=PERIOD:20240312T140000Z/PT2H\n" +
+			"EXDATE;TZID=America/New_York:20240306T093000\n",
+		"DTSTART:20240304T143000Z\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+			"RRULE:FREQ=YEARLY;BYMONTH=3\n" +
+			"RDATE:20240310T140000Z,20240311T140000Z\n" +
+			"RDATE;VALUE=PERIOD:20240312T140000Z/PT1H\n" +
+			"EXDATE;TZID=America/New_York:20240306T093000\n",
+		"DTSTART;TZID=America/New_York:20240304T093000\n" +
+			"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\n" +
+			"RRULE:FREQ=YEARLY;BYMONTH=3\n" +
+			"EXRULE:FREQ=MONTHLY\n" +
+			"RDATE:20240310T140000Z,20240311T140000Z\n" +
+			"RDATE;VALUE=PERIOD:20240312T140000Z/PT1H\n" +
+			"EXDATE;TZID=America/New_York:20240306T093000\n",
+	} {
+		assert.False(t, base.Equal(parse(src)), src)
+	}
+
+	// patterns take Dtstart from the recurrence, and are not modified.
+	r := Recurrence{
+		Dtstart: time.Date(2024, 3, 4, 9, 30, 0, 0, NewYork()),
+		RRules:  []RRule{{Frequency: Yearly, ByMonths: []time.Month{time.March}}},
+	}
+	assert.True(t, r.Equal(Recurrence{Dtstart: r.Dtstart, RRules: []RRule{{Frequency: Yearly}}}))
+	assert.True(t, r.RRules[0].Dtstart.IsZero())
+
+	allDay := parse("DTSTART;VALUE=DATE:20240304\nRDATE;VALUE=DATE:20240310\n")
+	assert.True(t, allDay.Equal(Recurrence{
+		Dtstart:          time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC),
+		FloatingLocation: true,
+		AllDay:           true,
+		RDates:           []time.Time{time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)},
+	}))
+	assert.False(t, allDay.Equal(parse("DTSTART:20240304T000000\nRDATE:20240310T000000\n")))
+	assert.True(t, Recurrence{}.Equal(Recurrence{}))
+}
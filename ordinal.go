@@ -0,0 +1,162 @@
+package rrule
+
+import "time"
+
+// Nth returns the occurrence of the pattern at zero-based position n, so that
+// Nth(0) is the first occurrence. The boolean is false if the pattern is
+// invalid or has fewer than n+1 occurrences.
+//
+// Patterns with no BYxxx rule parts, whose every period holds exactly one
+// occurrence, are indexed arithmetically. Others are iterated from Dtstart.
+func (rrule RRule) Nth(n int) (time.Time, bool) {
+	it, err := rrule.iterator()
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+
+	if it.arithmetic {
+		if rrule.Count > 0 && uint64(n) >= rrule.Count {
+			return time.Time{}, false
+		}
+		t, ok := it.occurrence(n)
+		if !ok || t.After(it.maxTime) {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	return nth(it, n)
+}
+
+// IndexOf returns the zero-based position of t among the occurrences of the
+// pattern. The boolean is false if t is not an occurrence.
+//
+// Like Nth, IndexOf is arithmetic for patterns with no BYxxx rule parts.
+// Otherwise, once Contains has confirmed that t is an occurrence, the pattern is
+// iterated from Dtstart to count the occurrences before it.
+func (rrule RRule) IndexOf(t time.Time) (int, bool) {
+	if !rrule.Contains(t) {
+		return 0, false
+	}
+
+	it, err := rrule.iterator()
+	if err != nil {
+		return 0, false
+	}
+
+	if it.arithmetic {
+		return it.countBefore(t, false), true
+	}
+
+	return indexOf(it, t)
+}
+
+// Nth returns the occurrence of the recurrence at zero-based position n. The
+// boolean is false if the recurrence is invalid or has fewer than n+1
+// occurrences. The recurrence is always iterated from its first occurrence.
+func (r Recurrence) Nth(n int) (time.Time, bool) {
+	it, err := r.iterator()
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	return nth(it, n)
+}
+
+// IndexOf returns the zero-based position of t among the occurrences of the
+// recurrence. The boolean is false if t is not an occurrence.
+func (r Recurrence) IndexOf(t time.Time) (int, bool) {
+	if !r.Contains(t) {
+		return 0, false
+	}
+
+	it, err := r.iterator()
+	if err != nil {
+		return 0, false
+	}
+	return indexOf(it, t)
+}
+
+// nth consumes the first n times of it and returns the next.
+func nth(it timeSource, n int) (time.Time, bool) {
+	for ; n > 0; n-- {
+		if _, ok := it.next(); !ok {
+			return time.Time{}, false
+		}
+	}
+	return it.next()
+}
+
+// indexOf consumes it until t, returning the number of times before it.
+func indexOf(it timeSource, t time.Time) (int, bool) {
+	idx := 0
+	for next, ok := it.next(); ok && !next.After(t); next, ok = it.next() {
+		if next.Equal(t) {
+			return idx, true
+		}
+		idx++
+	}
+	return 0, false
+}
+
+// arithmetic reports whether a pattern starting at start has exactly one
+// occurrence in every period, its key time. That requires no BYxxx rule
+// parts, a start that exists in every month or year the pattern visits, and
+// that clocks changing neither skip nor repeat any key time.
+func (rrule RRule) arithmetic(start time.Time) bool {
+	if len(rrule.BySeconds) > 0 ||
+		len(rrule.ByMinutes) > 0 ||
+		len(rrule.ByHours) > 0 ||
+		len(rrule.ByWeekdays) > 0 ||
+		len(rrule.ByMonthDays) > 0 ||
+		len(rrule.ByWeekNumbers) > 0 ||
+		len(rrule.ByMonths) > 0 ||
+		len(rrule.ByYearDays) > 0 ||
+		len(rrule.BySetPos) > 0 {
+		return false
+	}
+
+	if changesAfter(start) &&
+		(rrule.Frequency < Daily ||
+			rrule.NonexistentBehavior == SkipNonexistent ||
+			rrule.ambiguousBehavior() == BothAmbiguous) {
+		return false
+	}
+
+	switch rrule.Frequency {
+	case Monthly:
+		return start.Day() <= 28
+	case Yearly:
+		return start.Month() != time.February || start.Day() != 29
+	}
+	return true
+}
+
+// countBefore returns the number of occurrences of an arithmetic iterator
+// before t, or at or before t if inc is true, ignoring any limit on the
+// number or end of occurrences.
+func (i *iterator) countBefore(t time.Time, inc bool) int {
+	p := i.periodOf(t)
+	if p < 0 {
+		return 0
+	}
+
+	key, _ := i.occurrence(p)
+	if key.Before(t) || (inc && key.Equal(t)) {
+		p++
+	}
+	return p
+}
+
+// Ordinal returns the zero-based position of the time the next call to Next
+// will return. The boolean is false if the iterator has skipped over times
+// without counting them.
+func (i *iterator) Ordinal() (int, bool) {
+	return i.ordinal, !i.lostOrdinal
+}
+
+// Ordinal returns the zero-based position of the time the next call to Next
+// will return. The position is lost once SkipTo is used or the iterator is
+// reversed, since the patterns of a recurrence cannot be counted separately.
+func (ri *recurrenceIterator) Ordinal() (int, bool) {
+	return ri.ordinal, !ri.lostOrdinal
+}
@@ -0,0 +1,164 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNth(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			all := All(tc.RRule.Iterator(), 30)
+			for n, d := range all {
+				nth, ok := tc.RRule.Nth(n)
+				assert.True(t, ok, n)
+				assert.Equal(t, d, nth, n)
+
+				idx, ok := tc.RRule.IndexOf(d)
+				assert.True(t, ok, d)
+				assert.Equal(t, n, idx, d)
+			}
+
+			if tc.Terminal {
+				_, ok := tc.RRule.Nth(len(all))
+				assert.False(t, ok)
+			}
+
+			_, ok := tc.RRule.Nth(-1)
+			assert.False(t, ok)
+
+			if len(all) > 0 {
+				_, ok = tc.RRule.IndexOf(all[0].Add(time.Second / 2))
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestNthArithmetic(t *testing.T) {
+	for _, str := range []string{
+		"FREQ=SECONDLY;INTERVAL=7",
+		"FREQ=MINUTELY;INTERVAL=13",
+		"FREQ=HOURLY;INTERVAL=5",
+		"FREQ=DAILY;INTERVAL=3",
+		"FREQ=WEEKLY;INTERVAL=2",
+		"FREQ=MONTHLY",
+		"FREQ=YEARLY;INTERVAL=4",
+		"FREQ=DAILY;COUNT=150",
+		"FREQ=HOURLY;UNTIL=20180901T000000Z",
+	} {
+		for _, loc := range []*time.Location{NewYork(), time.UTC} {
+			t.Run(str+" "+loc.String(), func(t *testing.T) {
+				rrule := MustRRule(str)
+				rrule.Dtstart = time.Date(2018, 3, 10, 1, 30, 0, 0, loc)
+
+				// clocks changing skip and repeat the key times of
+				// HOURLY, MINUTELY, and SECONDLY patterns.
+				it, err := rrule.iterator()
+				require.NoError(t, err)
+				require.Equal(t, rrule.Frequency >= Daily || loc == time.UTC, it.arithmetic)
+
+				all := All(rrule.Iterator(), 200)
+				for n, d := range all {
+					nth, ok := rrule.Nth(n)
+					assert.True(t, ok, n)
+					assert.True(t, d.Equal(nth), "%d: %s != %s", n, d, nth)
+
+					idx, ok := rrule.IndexOf(d)
+					assert.True(t, ok, d)
+					assert.Equal(t, n, idx, d)
+				}
+
+				if len(all) < 200 {
+					_, ok := rrule.Nth(len(all))
+					assert.False(t, ok)
+				}
+			})
+		}
+	}
+
+	leap := RRule{Frequency: Yearly, Dtstart: time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)}
+	it, err := leap.iterator()
+	require.NoError(t, err)
+	assert.False(t, it.arithmetic)
+}
+
+func TestOrdinal(t *testing.T) {
+	rrule := RRule{Frequency: Daily, Dtstart: now}
+	it := rrule.Iterator().(OrdinalIterator)
+
+	for n := 0; n < 5; n++ {
+		ord, ok := it.Ordinal()
+		assert.True(t, ok)
+		assert.Equal(t, n, ord)
+		it.Next()
+	}
+
+	// an arithmetic pattern can count what it skips over
+	SkipTo(it, now.AddDate(0, 0, 100))
+	ord, ok := it.Ordinal()
+	assert.True(t, ok)
+	assert.Equal(t, 100, ord)
+	assert.Equal(t, now.AddDate(0, 0, 100), *it.Next())
+
+	rev, err := rrule.NewReverseIterator(now.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	ord, ok = rev.(OrdinalIterator).Ordinal()
+	assert.True(t, ok)
+	assert.Equal(t, 10, ord)
+	rev.Next()
+	ord, _ = rev.(OrdinalIterator).Ordinal()
+	assert.Equal(t, 9, ord)
+
+	// other patterns can't
+	rrule = RRule{Frequency: Daily, Dtstart: now, ByHours: []int{8, 20}}
+	it = rrule.Iterator().(OrdinalIterator)
+	it.Next()
+	ord, ok = it.Ordinal()
+	assert.True(t, ok)
+	assert.Equal(t, 1, ord)
+
+	SkipTo(it, now.AddDate(0, 0, 100))
+	_, ok = it.Ordinal()
+	assert.False(t, ok)
+
+	// unless they have a count, which is counted by scanning
+	rrule.Count = 300
+	it = rrule.Iterator().(OrdinalIterator)
+	SkipTo(it, now.AddDate(0, 0, 100))
+	ord, ok = it.Ordinal()
+	assert.True(t, ok)
+	assert.Equal(t, 200, ord)
+}
+
+func TestRecurrenceNth(t *testing.T) {
+	for _, tc := range recurrenceCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			all := All(tc.Recurrence.Iterator(), 30)
+			for n, d := range all {
+				nth, ok := tc.Recurrence.Nth(n)
+				assert.True(t, ok, n)
+				assert.Equal(t, d, nth, n)
+
+				idx, ok := tc.Recurrence.IndexOf(d)
+				assert.True(t, ok, d)
+				assert.Equal(t, n, idx, d)
+			}
+
+			it := tc.Recurrence.Iterator().(OrdinalIterator)
+			for n := range all {
+				ord, ok := it.Ordinal()
+				assert.True(t, ok)
+				assert.Equal(t, n, ord)
+				it.Next()
+			}
+		})
+	}
+}
@@ -16,6 +16,15 @@ import (
 // properties recognized are DTSTART, RRULE, EXRULE, RDATE, EXDATE. Others are
 // ignored.
 //
+// RDATE and EXDATE may list several comma-separated values. Besides DATE-TIME
+// values, they may be dates (VALUE=DATE), which are taken as midnight in loc
+// and recorded in DateValues, and an RDATE may be periods (VALUE=PERIOD),
+// which are added to RPeriods.
+//
+// A DTSTART that is a date makes the recurrence all-day. Its occurrences are
+// midnight of each date in loc, and FloatingLocation and AllDay are set. See
+// Recurrence.AllDay.
+//
 // loc defines what "local" means to the parsed rules. Some patterns may
 // specify a "floating" time, one without a timezone or offset, which matches
 // a different actual time in different timezones. For example,
@@ -32,65 +41,162 @@ import (
 // to count down to the ball dropping in New York's Times Square for each new year.
 //
 // If nil, time.UTC will be used.
+//
+// A TZID is first looked up among the VTIMEZONE components in src, whose own
+// properties are otherwise ignored, and then with LoadLocation. See
+// ParseTimezones.
+//
+// ParseRecurrence reads every other line of src as a property of one
+// recurrence, so it is meant for a single component. Use ParseCalendar for
+// whole iCalendar files with several events.
 func ParseRecurrence(src []byte, loc *time.Location) (*Recurrence, error) {
-	scanner := bufio.NewScanner(bytes.NewBuffer(src))
-
-	recurrence := &Recurrence{}
+	lines, err := parseContentLines(src)
+	if err != nil {
+		return nil, err
+	}
 
-	for scanner.Scan() {
-		text := scanner.Text()
-		colonIdx := strings.IndexAny(text, ":;")
+	lines, z, err := splitTimezones(lines)
+	if err != nil {
+		return nil, err
+	}
 
-		if colonIdx < 0 || len(text)-1 == colonIdx {
-			return nil, fmt.Errorf("misformatted line %q", text)
-		}
+	return parseRecurrenceLines(lines, z, loc)
+}
 
-		propName := text[:colonIdx]
-		propVal := text[colonIdx+1:]
+// parseRecurrenceLines builds a recurrence from the properties among lines,
+// ignoring the others. TZIDs are resolved with z.
+func parseRecurrenceLines(lines []contentLine, z zones, loc *time.Location) (*Recurrence, error) {
+	recurrence := &Recurrence{}
 
-		switch propName {
+	for _, line := range lines {
+		switch line.name {
 		case "DTSTART":
-			t, floating, err := parseTime(text, loc)
+			t, floating, date, err := parseDateOrTimeLine(line, z, loc)
 			if err != nil {
 				return nil, err
 			}
 			recurrence.Dtstart = t
 			recurrence.FloatingLocation = floating
+			recurrence.AllDay = date
 
 		case "RRULE":
-			rrule, err := ParseRRule(propVal)
+			rrule, err := ParseRRule(line.value)
 			if err != nil {
 				return nil, err
 			}
 			recurrence.RRules = append(recurrence.RRules, rrule)
 		case "EXRULE":
-			rrule, err := ParseRRule(propVal)
+			rrule, err := ParseRRule(line.value)
 			if err != nil {
 				return nil, err
 			}
 			recurrence.ExRules = append(recurrence.ExRules, rrule)
 		case "RDATE":
-			t, _, err := parseTime(propVal, loc)
+			times, dates, periods, err := parseDateList(line, z, loc)
 			if err != nil {
 				return nil, err
 			}
 
-			recurrence.RDates = append(recurrence.RDates, t)
+			recurrence.RDates = append(recurrence.RDates, times...)
+			recurrence.DateValues = append(recurrence.DateValues, dates...)
+			recurrence.RPeriods = append(recurrence.RPeriods, periods...)
 		case "EXDATE":
-			t, _, err := parseTime(propVal, loc)
+			times, dates, periods, err := parseDateList(line, z, loc)
 			if err != nil {
 				return nil, err
 			}
+			if len(periods) > 0 {
+				return nil, errors.New("EXDATE cannot have PERIOD values")
+			}
 
-			recurrence.ExDates = append(recurrence.ExDates, t)
+			recurrence.ExDates = append(recurrence.ExDates, times...)
+			recurrence.DateValues = append(recurrence.DateValues, dates...)
 		}
 	}
 
-	recurrence.setDtstart()
+	// every time of an all-day recurrence is a date.
+	if recurrence.AllDay {
+		recurrence.DateValues = nil
+	}
+
+	if err := recurrence.setDtstart(); err != nil {
+		return nil, err
+	}
 
 	return recurrence, nil
 }
 
+// parseDateOrTimeLine parses the DATE or DATE-TIME value of line. A date is
+// midnight in loc and is floating. date is true if the value was a date.
+func parseDateOrTimeLine(line contentLine, z zones, loc *time.Location) (t time.Time, floating, date bool, err error) {
+	if isDateLine(line) {
+		t, err = parseDateValue(line.value, loc)
+		return t, true, true, err
+	}
+
+	t, floating, err = parseTimeLine(line, z, loc)
+	return t, floating, false, err
+}
+
+// isDateLine reports whether the value of line is a DATE, either because it
+// says so with VALUE=DATE or because it has no VALUE parameter and is only as
+// long as a date.
+func isDateLine(line contentLine) bool {
+	valueType, ok := line.param("VALUE")
+	if ok {
+		return strings.EqualFold(valueType, "DATE")
+	}
+	return len(line.value) == len(rfc5545Date)
+}
+
+// parseDateList parses the comma-separated values of an RDATE or EXDATE line,
+// according to its VALUE parameter. dates holds the times that were dates.
+func parseDateList(line contentLine, z zones, loc *time.Location) (times, dates []time.Time, periods []Period, err error) {
+	zone, err := z.lineZone(line)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	valueType, _ := line.param("VALUE")
+
+	for _, value := range strings.Split(line.value, ",") {
+		switch strings.ToUpper(valueType) {
+		case "", "DATE-TIME":
+			if valueType == "" && len(value) == len(rfc5545Date) {
+				t, err := parseDateValue(value, loc)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+				times = append(times, t)
+				dates = append(dates, t)
+				break
+			}
+
+			t, _, err := parseTimeValue(value, zone, loc)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			times = append(times, t)
+		case "DATE":
+			t, err := parseDateValue(value, loc)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			times = append(times, t)
+			dates = append(dates, t)
+		case "PERIOD":
+			p, err := parsePeriod(value, zone, loc)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			periods = append(periods, p)
+		default:
+			return nil, nil, nil, fmt.Errorf("unsupported %s value type %q", line.name, valueType)
+		}
+	}
+
+	return times, dates, periods, nil
+}
+
 // ParseRRule parses a single RRule pattern.
 func ParseRRule(str string) (RRule, error) {
 	scanner := bufio.NewScanner(bytes.NewBufferString(str))
@@ -129,6 +235,16 @@ func ParseRRule(str string) (RRule, error) {
 			}
 			rrule.Frequency = freq
 		case "UNTIL":
+			if len(value) == len(rfc5545Date) {
+				t, err := parseDateValue(value, nil)
+				if err != nil {
+					return rrule, err
+				}
+				rrule.Until = t
+				rrule.UntilDate = true
+				break
+			}
+
 			t, floating, err := parseTime(wholeComponent, nil)
 			if err != nil {
 				return rrule, err
@@ -147,6 +263,11 @@ func ParseRRule(str string) (RRule, error) {
 			if err != nil {
 				return rrule, err
 			}
+			if i <= 0 {
+				v := &ValidationError{}
+				v.add("INTERVAL", value, "INTERVAL must be a positive integer")
+				return rrule, v
+			}
 			rrule.Interval = i
 		case "BYSECOND":
 			ints, err := parseInts(value, 0, 60, true)
@@ -0,0 +1,191 @@
+package rrule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is a span of time included in a recurrence by an RDATE with
+// VALUE=PERIOD. Only its Start is an occurrence of the recurrence; the rest of
+// the span is informational.
+//
+// A period is written either as a start and an end or as a start and a
+// duration. If Duration is non-zero, the period is written in the second form,
+// and End is ignored. ParseRecurrence sets both, so that End is always
+// Start plus Duration for a period written with a duration. Whole days of
+// Duration are nominal, as RFC 5545 specifies: they advance the date and keep
+// the time of day, even across a change of the clocks.
+type Period struct {
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+}
+
+// parsePeriod parses a PERIOD value, which is either start/end or
+// start/duration. The start and end are parsed like DATE-TIME values.
+func parsePeriod(str string, zone, defaultLoc *time.Location) (Period, error) {
+	slash := strings.IndexByte(str, '/')
+	if slash < 0 {
+		return Period{}, fmt.Errorf("invalid period %q: missing '/'", str)
+	}
+
+	start, _, err := parseTimeValue(str[:slash], zone, defaultLoc)
+	if err != nil {
+		return Period{}, err
+	}
+
+	p := Period{Start: start}
+
+	rest := str[slash+1:]
+	if strings.HasPrefix(rest, "P") || strings.HasPrefix(rest, "+") || strings.HasPrefix(rest, "-") {
+		d, err := parseDuration(rest)
+		if err != nil {
+			return Period{}, err
+		}
+		if d <= 0 {
+			return Period{}, fmt.Errorf("invalid period %q: duration must be positive", str)
+		}
+		p.Duration = d
+		p.End = addDuration(start, d)
+		return p, nil
+	}
+
+	p.End, _, err = parseTimeValue(rest, zone, defaultLoc)
+	if err != nil {
+		return Period{}, err
+	}
+	if !p.End.After(p.Start) {
+		return Period{}, fmt.Errorf("invalid period %q: end must be after start", str)
+	}
+	return p, nil
+}
+
+// formatPeriod returns the PERIOD value of p. The end is written in the same
+// form as the start.
+func formatPeriod(p Period, floatingLocation bool) string {
+	_, start := formatTimeParts(p.Start, floatingLocation)
+	if p.Duration != 0 {
+		return start + "/" + formatDuration(p.Duration)
+	}
+	_, end := formatTimeParts(p.End.In(p.Start.Location()), floatingLocation)
+	return start + "/" + end
+}
+
+// parseDuration parses a DURATION value, as described in section 3.3.6 of RFC
+// 5545, such as P15DT5H0M20S or -PT15M. Days and weeks are returned as 24
+// hours and 7 days, which addDuration adds as nominal days.
+func parseDuration(str string) (time.Duration, error) {
+	s := str
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return 0, fmt.Errorf("invalid duration %q", str)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			if inTime || len(s) == 1 {
+				return 0, fmt.Errorf("invalid duration %q", str)
+			}
+			inTime = true
+			s = s[1:]
+			continue
+		}
+
+		end := 0
+		for end < len(s) && '0' <= s[end] && s[end] <= '9' {
+			end++
+		}
+		if end == 0 || end == len(s) {
+			return 0, fmt.Errorf("invalid duration %q", str)
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %v", str, err)
+		}
+
+		var unit time.Duration
+		switch designator := s[end]; {
+		case designator == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case designator == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case designator == 'H' && inTime:
+			unit = time.Hour
+		case designator == 'M' && inTime:
+			unit = time.Minute
+		case designator == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q: unexpected %q", str, designator)
+		}
+
+		d += time.Duration(n) * unit
+		s = s[end+1:]
+	}
+
+	return sign * d, nil
+}
+
+// addDuration returns t plus d, with the whole days of d added to the date of
+// t, and the rest as elapsed time, as for a DURATION value.
+func addDuration(t time.Time, d time.Duration) time.Time {
+	const day = 24 * time.Hour
+	return t.AddDate(0, 0, int(d/day)).Add(d % day)
+}
+
+// formatDuration returns the DURATION value of d, truncated to seconds.
+func formatDuration(d time.Duration) string {
+	b := &strings.Builder{}
+	if d < 0 {
+		b.WriteString("-")
+		d = -d
+	}
+	b.WriteString("P")
+
+	const day = 24 * time.Hour
+	if d >= 7*day && d%(7*day) == 0 {
+		fmt.Fprintf(b, "%dW", d/(7*day))
+		return b.String()
+	}
+
+	days := d / day
+	if days > 0 {
+		fmt.Fprintf(b, "%dD", days)
+		d %= day
+	}
+
+	h, m, s := d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second
+	if h == 0 && m == 0 && s == 0 {
+		if days == 0 {
+			b.WriteString("T0S")
+		}
+		return b.String()
+	}
+
+	// The grammar allows hours, minutes, and seconds to be omitted only from
+	// either end, so a zero in the middle is written out.
+	b.WriteString("T")
+	if h > 0 {
+		fmt.Fprintf(b, "%dH", h)
+	}
+	if m > 0 || (h > 0 && s > 0) {
+		fmt.Fprintf(b, "%dM", m)
+	}
+	if s > 0 {
+		fmt.Fprintf(b, "%dS", s)
+	}
+	return b.String()
+}
@@ -0,0 +1,157 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuration(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Duration time.Duration
+		Output   string
+	}{
+		{Input: "P15DT5H0M20S", Duration: 15*24*time.Hour + 5*time.Hour + 20*time.Second, Output: "P15DT5H0M20S"},
+		{Input: "P7W", Duration: 7 * 7 * 24 * time.Hour, Output: "P7W"},
+		{Input: "P14D", Duration: 14 * 24 * time.Hour, Output: "P2W"},
+		{Input: "+PT2H30M", Duration: 2*time.Hour + 30*time.Minute, Output: "PT2H30M"},
+		{Input: "-PT15M", Duration: -15 * time.Minute, Output: "-PT15M"},
+		{Input: "PT90S", Duration: 90 * time.Second, Output: "PT1M30S"},
+		{Input: "P1D", Duration: 24 * time.Hour, Output: "P1D"},
+		{Input: "PT0S", Duration: 0, Output: "PT0S"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Input, func(t *testing.T) {
+			d, err := parseDuration(tc.Input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.Duration, d)
+			assert.Equal(t, tc.Output, formatDuration(d))
+		})
+	}
+
+	for _, input := range []string{"", "P", "1D", "PT", "P1H", "PT1D", "P1DT", "PTT1H", "P1", "PxD"} {
+		_, err := parseDuration(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	p, err := parsePeriod("19970101T180000Z/19970102T070000Z", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Period{
+		Start: time.Date(1997, 1, 1, 18, 0, 0, 0, time.UTC),
+		End:   time.Date(1997, 1, 2, 7, 0, 0, 0, time.UTC),
+	}, p)
+	assert.Equal(t, "19970101T180000Z/19970102T070000Z", formatPeriod(p, false))
+
+	p, err = parsePeriod("19970101T180000/PT5H30M", NewYork(), nil)
+	require.NoError(t, err)
+	assert.True(t, time.Date(1997, 1, 1, 18, 0, 0, 0, NewYork()).Equal(p.Start))
+	assert.True(t, time.Date(1997, 1, 1, 23, 30, 0, 0, NewYork()).Equal(p.End))
+	assert.Equal(t, 5*time.Hour+30*time.Minute, p.Duration)
+	assert.Equal(t, "19970101T180000/PT5H30M", formatPeriod(p, false))
+
+	// days are nominal, so a day across a change of the clocks keeps the
+	// time of day, while hours are elapsed time.
+	p, err = parsePeriod("20240309T090000/P1DT2H", NewYork(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-10T11:00:00-04:00", p.End.Format(time.RFC3339))
+	assert.Equal(t, 26*time.Hour, p.Duration)
+	assert.Equal(t, "20240309T090000/P1DT2H", formatPeriod(p, false))
+
+	for _, input := range []string{
+		"19970101T180000Z",
+		"19970101T180000Z/",
+		"19970101T180000Z/19970101T170000Z",
+		"19970101T180000Z/-PT1H",
+		"bad/PT1H",
+	} {
+		_, err := parsePeriod(input, nil, nil)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseRecurrenceDateLists(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	src := "DTSTART;TZID=Europe/Berlin:20240101T090000\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=5\n" +
+		"EXDATE;TZID=Europe/Berlin:20240108T090000,20240122T090000\n" +
+		"RDATE;VALUE=DATE:20240103,20240104\n" +
+		"RDATE;VALUE=PERIOD;TZID=Europe/Berlin:20240110T140000/20240110T153000,20240111T140000/PT1H\n" +
+		"RDATE:20240112T120000Z\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 8, 9, 0, 0, 0, berlin),
+		time.Date(2024, 1, 22, 9, 0, 0, 0, berlin),
+	}, r.ExDates)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 12, 12, 0, 0, 0, time.UTC),
+	}, r.RDates)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC),
+	}, r.DateValues)
+	require.Len(t, r.RPeriods, 2)
+	assert.True(t, time.Date(2024, 1, 10, 15, 30, 0, 0, berlin).Equal(r.RPeriods[0].End))
+	assert.Equal(t, time.Hour, r.RPeriods[1].Duration)
+
+	assert.Equal(t, []string{
+		"2024-01-01T09:00:00+01:00",
+		"2024-01-03T00:00:00Z",
+		"2024-01-04T00:00:00Z",
+		"2024-01-10T14:00:00+01:00",
+		"2024-01-11T14:00:00+01:00",
+		"2024-01-12T12:00:00Z",
+		"2024-01-15T09:00:00+01:00",
+		"2024-01-29T09:00:00+01:00",
+	}, rfcAll(All(r.Iterator(), 0)))
+
+	assert.True(t, r.Contains(time.Date(2024, 1, 11, 14, 0, 0, 0, berlin)))
+
+	// dates are written back as dates, rather than as midnight UTC.
+	str := "DTSTART;TZID=Europe/Berlin:20240101T090000\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=5\n" +
+		"RDATE:20240112T120000Z\n" +
+		"RDATE;VALUE=DATE:20240103,20240104\n" +
+		"RDATE;VALUE=PERIOD;TZID=Europe/Berlin:20240110T140000/20240110T153000,20240111T140000/PT1H\n" +
+		"EXDATE;TZID=Europe/Berlin:20240108T090000,20240122T090000\n"
+	assert.Equal(t, str, r.String())
+
+	reparsed, err := ParseRecurrence([]byte(str), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, str, reparsed.String())
+
+	for _, src := range []string{
+		"DTSTART:20240101T090000Z\nEXDATE;VALUE=PERIOD:20240101T090000Z/PT1H\n",
+		"DTSTART:20240101T090000Z\nRDATE;VALUE=BINARY:AAAA\n",
+		"DTSTART:20240101T090000Z\nRDATE;VALUE=DATE:20240101T090000Z\n",
+		"DTSTART:20240101T090000Z\nRDATE:20240101T090000Z,bad\n",
+	} {
+		_, err := ParseRecurrence([]byte(src), nil)
+		assert.Error(t, err, src)
+	}
+}
+
+func TestDateValuedExDates(t *testing.T) {
+	src := "DTSTART:20240101T000000Z\n" +
+		"RRULE:FREQ=DAILY;COUNT=3\n" +
+		"EXDATE:20240102T000000Z\n" +
+		"EXDATE;VALUE=DATE:20240103\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2024-01-01T00:00:00Z"}, rfcAll(All(r.Iterator(), 0)))
+	assert.Equal(t, src, r.String())
+}
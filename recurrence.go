@@ -1,6 +1,9 @@
 package rrule
 
 import (
+	"errors"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 )
@@ -21,6 +24,20 @@ type Recurrence struct {
 	// detail.
 	FloatingLocation bool
 
+	// AllDay is true if the recurrence is of dates rather than times, as for
+	// an all-day event with DTSTART;VALUE=DATE. Its occurrences are then
+	// midnight of each date in the location of Dtstart. Dtstart, RDates, and
+	// ExDates are moved to midnight of their dates in that location, and each
+	// pattern's Until is a date. As RFC 5545 requires, BYHOUR, BYMINUTE, and
+	// BYSECOND are ignored.
+	//
+	// Dtstart, RDates, ExDates, and the Until of each pattern are encoded with
+	// VALUE=DATE.
+	//
+	// An all-day recurrence must have a Dtstart. Without one, NewIterator
+	// returns an error and the recurrence has no occurrences.
+	AllDay bool
+
 	// Patterns and instances to include. Repeated instances are included only
 	// once, even if defined by multiple patterns.
 	//
@@ -29,6 +46,10 @@ type Recurrence struct {
 	RRules []RRule
 	RDates []time.Time
 
+	// Periods to include, such as from RDATE;VALUE=PERIOD. The start of each
+	// period is included like an RDate.
+	RPeriods []Period
+
 	// Patterns and instances to exclude. These take precedence over the
 	// inclusions. Note: this feature was deprecated in RFC5545, noting its
 	// limited (and buggy) adoption and real-world use case. It is
@@ -36,6 +57,12 @@ type Recurrence struct {
 	// compatibility.
 	ExRules []RRule
 	ExDates []time.Time
+
+	// DateValues holds the RDates and ExDates that are dates rather than
+	// times, such as from RDATE;VALUE=DATE, in a recurrence that is not
+	// all-day. Each is midnight of its date and is included or excluded like
+	// any other time, but is encoded with VALUE=DATE.
+	DateValues []time.Time
 }
 
 // String returns the RFC 5545 representation of the recurrence, which is a
@@ -43,32 +70,137 @@ type Recurrence struct {
 func (r *Recurrence) String() string {
 	b := &strings.Builder{}
 	if !r.Dtstart.IsZero() {
-		b.WriteString(formatTime("DTSTART", r.Dtstart, r.FloatingLocation))
+		if r.AllDay {
+			b.WriteString("DTSTART;VALUE=DATE:")
+			b.WriteString(r.Dtstart.Format(rfc5545Date))
+		} else {
+			b.WriteString(formatTime("DTSTART", r.Dtstart, r.FloatingLocation))
+		}
 		b.WriteString("\n")
 	}
 	for _, rrule := range r.RRules {
+		if r.AllDay {
+			rrule = dateRule(rrule)
+		}
 		b.WriteString("RRULE:")
 		b.WriteString(rrule.String())
 		b.WriteString("\n")
 	}
 	for _, exrule := range r.ExRules {
+		if r.AllDay {
+			exrule = dateRule(exrule)
+		}
 		b.WriteString("EXRULE:")
 		b.WriteString(exrule.String())
 		b.WriteString("\n")
 	}
-	for _, rdate := range r.RDates {
-		b.WriteString(formatTime("RDATE", rdate, r.FloatingLocation))
-		b.WriteString("\n")
+	if r.AllDay {
+		writeDateList(b, "RDATE", r.RDates)
+	} else {
+		times, dates := splitDates(r.RDates, r.DateValues)
+		writeTimeList(b, "RDATE", times, r.FloatingLocation)
+		writeDateList(b, "RDATE", dates)
 	}
-	for _, exdate := range r.ExDates {
-		b.WriteString(formatTime("EXDATE", exdate, r.FloatingLocation))
-		b.WriteString("\n")
+	writePeriodList(b, "RDATE", r.RPeriods, r.FloatingLocation)
+	if r.AllDay {
+		writeDateList(b, "EXDATE", r.ExDates)
+	} else {
+		times, dates := splitDates(r.ExDates, r.DateValues)
+		writeTimeList(b, "EXDATE", times, r.FloatingLocation)
+		writeDateList(b, "EXDATE", dates)
 	}
 
 	return b.String()
 }
 
-func (r *Recurrence) setDtstart() {
+// splitDates separates tt into its times and its dates, which are those among
+// dateValues.
+func splitDates(tt, dateValues []time.Time) (times, dates []time.Time) {
+	if len(dateValues) == 0 {
+		return tt, nil
+	}
+	for _, t := range tt {
+		if slices.ContainsFunc(dateValues, t.Equal) {
+			dates = append(dates, t)
+		} else {
+			times = append(times, t)
+		}
+	}
+	return times, dates
+}
+
+// writeTimeList writes tt as comma-separated lists of the named property, one
+// line for each distinct location, in the order the locations first appear.
+func writeTimeList(b *strings.Builder, name string, tt []time.Time, floatingLocation bool) {
+	var params, values []string
+	for _, t := range tt {
+		p, v := formatTimeParts(t, floatingLocation)
+		values = appendToGroup(&params, values, p, v)
+	}
+	writeGroups(b, name, params, values)
+}
+
+// writeDateList writes tt as a comma-separated list of dates of the named
+// property.
+func writeDateList(b *strings.Builder, name string, tt []time.Time) {
+	if len(tt) == 0 {
+		return
+	}
+	values := make([]string, len(tt))
+	for i, t := range tt {
+		values[i] = t.Format(rfc5545Date)
+	}
+	writeGroups(b, name, []string{";VALUE=DATE"}, []string{strings.Join(values, ",")})
+}
+
+// writePeriodList writes periods like writeTimeList, with VALUE=PERIOD.
+func writePeriodList(b *strings.Builder, name string, periods []Period, floatingLocation bool) {
+	var params, values []string
+	for _, period := range periods {
+		p, _ := formatTimeParts(period.Start, floatingLocation)
+		values = appendToGroup(&params, values, ";VALUE=PERIOD"+p, formatPeriod(period, floatingLocation))
+	}
+	writeGroups(b, name, params, values)
+}
+
+// appendToGroup adds value to the comma-separated list in values with the same
+// index as p in params, adding p if it is new.
+func appendToGroup(params *[]string, values []string, p, value string) []string {
+	for i, existing := range *params {
+		if existing == p {
+			values[i] += "," + value
+			return values
+		}
+	}
+	*params = append(*params, p)
+	return append(values, value)
+}
+
+func writeGroups(b *strings.Builder, name string, params, values []string) {
+	for i := range params {
+		b.WriteString(name)
+		b.WriteString(params[i])
+		b.WriteString(":")
+		b.WriteString(values[i])
+		b.WriteString("\n")
+	}
+}
+
+// errAllDayStart is returned for an all-day recurrence without a Dtstart,
+// which has neither a date to begin on nor a location for its dates.
+var errAllDayStart = errors.New("an all-day recurrence must have a Dtstart")
+
+// setDtstart gives every pattern the Dtstart of the recurrence, after
+// anchoring an all-day recurrence to its dates. An all-day recurrence without
+// a Dtstart is left as it is, and errAllDayStart is returned.
+func (r *Recurrence) setDtstart() error {
+	if r.AllDay {
+		if r.Dtstart.IsZero() {
+			return errAllDayStart
+		}
+		r.anchorDates()
+	}
+
 	for i, rr := range r.RRules {
 		rr.Dtstart = r.Dtstart
 		r.RRules[i] = rr
@@ -77,6 +209,49 @@ func (r *Recurrence) setDtstart() {
 		rr.Dtstart = r.Dtstart
 		r.ExRules[i] = rr
 	}
+	return nil
+}
+
+// anchorDates moves the times of an all-day recurrence to midnight of their
+// dates in the location of Dtstart, and drops the rule parts that RFC 5545 says
+// to ignore for dates. Slices are copied rather than modified.
+func (r *Recurrence) anchorDates() {
+	loc := r.Dtstart.Location()
+	r.Dtstart = midnight(r.Dtstart, loc)
+
+	dates := func(tt []time.Time) []time.Time {
+		anchored := make([]time.Time, len(tt))
+		for i, t := range tt {
+			anchored[i] = midnight(t, loc)
+		}
+		return anchored
+	}
+	r.RDates = dates(r.RDates)
+	r.ExDates = dates(r.ExDates)
+
+	rules := func(rr []RRule) []RRule {
+		anchored := make([]RRule, len(rr))
+		for i, rule := range rr {
+			anchored[i] = dateRule(rule)
+		}
+		return anchored
+	}
+	r.RRules = rules(r.RRules)
+	r.ExRules = rules(r.ExRules)
+}
+
+// dateRule returns rule as a pattern of dates, with Until taken as a date and
+// without BYHOUR, BYMINUTE, or BYSECOND.
+func dateRule(rule RRule) RRule {
+	rule.UntilDate = !rule.Until.IsZero()
+	rule.BySeconds, rule.ByMinutes, rule.ByHours = nil, nil, nil
+	return rule
+}
+
+// midnight returns the start of the date of t, as written, in loc.
+func midnight(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
 }
 
 // All returns all instances from the beginning of the iterator up to a limited
@@ -97,43 +272,145 @@ func All(it Iterator, limit int) []time.Time {
 	return all
 }
 
-// Iterator returns an iterator for the recurrence.
+// Iterator returns an iterator for the recurrence. Every pattern must be valid
+// or Iterator will panic.
 func (r Recurrence) Iterator() Iterator {
-	r.setDtstart()
+	it, err := r.NewIterator()
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+// NewIterator returns an iterator for the recurrence, or an error if any of
+// its patterns is invalid.
+func (r Recurrence) NewIterator() (Iterator, error) {
+	ri, err := r.iterator()
+	if err != nil {
+		return nil, err
+	}
+	return ri, nil
+}
+
+// NewTimeIterator returns a TimeIterator for the recurrence, or an error if any
+// of its patterns is invalid.
+func (r Recurrence) NewTimeIterator() (TimeIterator, error) {
+	ri, err := r.iterator()
+	if err != nil {
+		return nil, err
+	}
+	return timeIterator{ri}, nil
+}
+
+func (r Recurrence) iterator() (*recurrenceIterator, error) {
+	if err := r.setDtstart(); err != nil {
+		return nil, err
+	}
+
+	rrules, err := groupIteratorFromRRules(r.RRules)
+	if err != nil {
+		return nil, err
+	}
+	exrules, err := groupIteratorFromRRules(r.ExRules)
+	if err != nil {
+		return nil, err
+	}
 
 	ri := &recurrenceIterator{
-		rrules:  groupIteratorFromRRules(r.RRules),
-		exrules: groupIteratorFromRRules(r.ExRules),
+		rrules:  rrules,
+		exrules: exrules,
 	}
 
-	ri.rrules.iters = append(ri.rrules.iters, &iterator{queue: r.RDates})
-	ri.exrules.iters = append(ri.exrules.iters, &iterator{queue: r.ExDates})
+	ri.rrules.iters = append(ri.rrules.iters, &iterator{queue: sortedTimes(r.rdates())})
+	ri.exrules.iters = append(ri.exrules.iters, &iterator{queue: sortedTimes(r.ExDates)})
 
-	return ri
+	return ri, nil
+}
+
+// rdates returns the RDates along with the starts of the RPeriods.
+func (r Recurrence) rdates() []time.Time {
+	if len(r.RPeriods) == 0 {
+		return r.RDates
+	}
+	rdates := make([]time.Time, 0, len(r.RDates)+len(r.RPeriods))
+	rdates = append(rdates, r.RDates...)
+	for _, p := range r.RPeriods {
+		rdates = append(rdates, p.Start)
+	}
+	return rdates
+}
+
+// sortedTimes returns a sorted copy of tt, without duplicates.
+func sortedTimes(tt []time.Time) []time.Time {
+	sorted := make([]time.Time, 0, len(tt))
+	sorted = append(sorted, tt...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+
+	deduped := sorted[:0]
+	for _, t := range sorted {
+		if len(deduped) > 0 && t.Equal(deduped[len(deduped)-1]) {
+			continue
+		}
+		deduped = append(deduped, t)
+	}
+	return deduped
 }
 
 type recurrenceIterator struct {
 	rrules  *groupIterator
 	exrules *groupIterator
+
+	// descending is true if the groups return times in descending order.
+	descending bool
+
+	ordinal     int
+	lostOrdinal bool
+}
+
+// SkipTo advances the iterator so that the next time returned is the first at
+// or after t, or at or before t if the iterator is descending.
+func (ri *recurrenceIterator) SkipTo(t time.Time) {
+	ri.lostOrdinal = true
+	ri.rrules.SkipTo(t)
+	ri.exrules.SkipTo(t)
 }
 
 func (ri *recurrenceIterator) Peek() *time.Time {
-	next := ri.rrules.Peek()
+	t, ok := ri.peek()
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (ri *recurrenceIterator) Next() *time.Time {
+	t, ok := ri.next()
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+func (ri *recurrenceIterator) peek() (time.Time, bool) {
+	next, ok := ri.rrules.peek()
 
 	for {
-		if next == nil {
-			return nil
+		if !ok {
+			return time.Time{}, false
 		}
 
-		nextException := ri.exrules.Peek()
+		nextException, exOK := ri.exrules.peek()
 
-		if nextException != nil && nextException.Before(*next) {
-			ri.exrules.Next()
+		if exOK && ri.exrules.ahead(nextException, next) {
+			ri.exrules.next()
 			continue
 		}
 
-		if nextException != nil && nextException.Equal(*next) {
-			next = ri.rrules.Next()
+		if exOK && nextException.Equal(next) {
+			ri.rrules.next()
+			next, ok = ri.rrules.peek()
 
 			continue
 		}
@@ -141,11 +418,15 @@ func (ri *recurrenceIterator) Peek() *time.Time {
 		break
 	}
 
-	return next
+	return next, true
 }
 
-func (ri *recurrenceIterator) Next() *time.Time {
-	t := ri.Peek()
-	ri.rrules.Next()
-	return t
+func (ri *recurrenceIterator) next() (time.Time, bool) {
+	t, ok := ri.peek()
+	if !ok {
+		return t, false
+	}
+	ri.rrules.next()
+	ri.ordinal++
+	return t, true
 }
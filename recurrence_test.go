@@ -56,8 +56,38 @@ var recurrenceCases = []struct {
 		},
 		ExDates: []time.Time{time.Date(2018, time.September, 2, 9, 8, 7, 0, time.UTC)},
 	},
-	Dates:  []string{"2018-08-26T09:08:07Z", "2018-08-28T09:08:07Z", "2018-08-31T09:08:07Z", "2018-09-04T09:08:07Z", "2018-09-08T09:08:07Z"},
-	String: "DTSTART:20180825T090807Z\nRRULE:FREQ=DAILY;COUNT=4\nRRULE:FREQ=DAILY;COUNT=8;INTERVAL=2\nEXRULE:FREQ=DAILY;INTERVAL=4\nEXRULE:FREQ=DAILY;INTERVAL=8\nRDATE:20180902T090807Z\nRDATE:20180902T090807Z\nEXDATE:20180902T090807Z\n",
+	Dates:  []string{"2018-08-26T09:08:07Z", "2018-08-27T09:08:07Z", "2018-08-28T09:08:07Z", "2018-08-31T09:08:07Z", "2018-09-04T09:08:07Z", "2018-09-08T09:08:07Z"},
+	String: "DTSTART:20180825T090807Z\nRRULE:FREQ=DAILY;COUNT=4\nRRULE:FREQ=DAILY;COUNT=8;INTERVAL=2\nEXRULE:FREQ=DAILY;INTERVAL=4\nEXRULE:FREQ=DAILY;INTERVAL=8\nRDATE:20180902T090807Z,20180902T090807Z\nEXDATE:20180902T090807Z\n",
+}, {
+	Name: "Lists",
+	Recurrence: &Recurrence{
+		Dtstart: now,
+		RRules: []RRule{
+			{Frequency: Weekly, Count: 3},
+		},
+		RDates: []time.Time{
+			time.Date(2018, time.August, 26, 10, 0, 0, 0, time.UTC),
+			time.Date(2018, time.August, 27, 10, 0, 0, 0, NewYork()),
+			time.Date(2018, time.August, 28, 10, 0, 0, 0, time.UTC),
+		},
+		RPeriods: []Period{
+			{Start: time.Date(2018, time.August, 29, 8, 0, 0, 0, time.UTC), End: time.Date(2018, time.August, 29, 9, 30, 0, 0, time.UTC)},
+			{Start: time.Date(2018, time.August, 30, 8, 0, 0, 0, time.UTC), Duration: 2 * time.Hour},
+		},
+		ExDates: []time.Time{now.AddDate(0, 0, 7), now.AddDate(0, 0, 14)},
+	},
+	Dates: []string{
+		"2018-08-25T09:08:07Z",
+		"2018-08-26T10:00:00Z",
+		"2018-08-27T10:00:00-04:00",
+		"2018-08-28T10:00:00Z",
+		"2018-08-29T08:00:00Z",
+		"2018-08-30T08:00:00Z",
+	},
+	String: "DTSTART:20180825T090807Z\nRRULE:FREQ=WEEKLY;COUNT=3\n" +
+		"RDATE:20180826T100000Z,20180828T100000Z\nRDATE;TZID=America/New_York:20180827T100000\n" +
+		"RDATE;VALUE=PERIOD:20180829T080000Z/20180829T093000Z,20180830T080000Z/PT2H\n" +
+		"EXDATE:20180901T090807Z,20180908T090807Z\n",
 }}
 
 func TestRecurrence(t *testing.T) {
@@ -81,3 +111,83 @@ func TestRecurrence(t *testing.T) {
 		})
 	}
 }
+
+func TestAllDay(t *testing.T) {
+	src := "DTSTART;VALUE=DATE:20240105\n" +
+		"RRULE:FREQ=WEEKLY;BYHOUR=9;UNTIL=20240202\n" +
+		"RDATE;VALUE=DATE:20240110,20240111\n" +
+		"EXDATE:20240119\n"
+
+	r, err := ParseRecurrence([]byte(src), NewYork())
+	require.NoError(t, err)
+	assert.True(t, r.AllDay)
+	assert.True(t, r.FloatingLocation)
+	assert.Equal(t, time.Date(2024, 1, 5, 0, 0, 0, 0, NewYork()), r.Dtstart)
+
+	assert.Equal(t, []string{
+		"2024-01-05T00:00:00-05:00",
+		"2024-01-10T00:00:00-05:00",
+		"2024-01-11T00:00:00-05:00",
+		"2024-01-12T00:00:00-05:00",
+		"2024-01-26T00:00:00-05:00",
+		"2024-02-02T00:00:00-05:00",
+	}, rfcAll(All(r.Iterator(), 0)))
+
+	assert.Equal(t, "DTSTART;VALUE=DATE:20240105\n"+
+		"RRULE:FREQ=WEEKLY;UNTIL=20240202\n"+
+		"RDATE;VALUE=DATE:20240110,20240111\n"+
+		"EXDATE;VALUE=DATE:20240119\n", r.String())
+
+	// times are moved to midnight of their dates, in the location of Dtstart.
+	built := Recurrence{
+		Dtstart: time.Date(2024, 3, 9, 15, 30, 0, 0, NewYork()),
+		AllDay:  true,
+		RRules:  []RRule{{Frequency: Daily, ByHours: []int{8, 20}, Until: time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)}},
+		ExDates: []time.Time{time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC)},
+	}
+	assert.Equal(t, []string{
+		"2024-03-09T00:00:00-05:00",
+		"2024-03-10T00:00:00-05:00",
+		"2024-03-12T00:00:00-04:00",
+	}, rfcAll(All(built.Iterator(), 0)))
+	assert.True(t, built.Contains(time.Date(2024, 3, 12, 0, 0, 0, 0, NewYork())))
+	assert.Equal(t, []int{8, 20}, built.RRules[0].ByHours)
+
+	n, ok := built.CountOccurrences()
+	assert.True(t, ok)
+	assert.Equal(t, 3, n)
+
+	assert.Equal(t, "DTSTART;VALUE=DATE:20240309\n"+
+		"RRULE:FREQ=DAILY;UNTIL=20240312\n"+
+		"EXDATE;VALUE=DATE:20240311\n", built.String())
+
+	// without a Dtstart, no start is made up from the current time.
+	unstarted := Recurrence{AllDay: true, RRules: []RRule{{Frequency: Daily, Count: 3}}}
+	_, err = unstarted.NewIterator()
+	assert.Error(t, err)
+	_, err = unstarted.NewReverseIterator(time.Time{})
+	assert.Error(t, err)
+	assert.False(t, unstarted.Contains(midnight(time.Now(), time.Local)))
+	_, ok = unstarted.CountOccurrences()
+	assert.False(t, ok)
+	assert.True(t, unstarted.Equal(unstarted))
+	assert.True(t, unstarted.Dtstart.IsZero())
+}
+
+func TestRecurrenceUnsortedLists(t *testing.T) {
+	r := Recurrence{
+		Dtstart: time.Date(2018, time.September, 1, 9, 0, 0, 0, time.UTC),
+		RDates: []time.Time{
+			time.Date(2018, time.September, 5, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, time.September, 5, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, time.September, 4, 9, 0, 0, 0, time.UTC),
+		},
+		ExDates: []time.Time{
+			time.Date(2018, time.September, 4, 9, 0, 0, 0, time.UTC),
+			time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	assert.Equal(t, []string{"2018-09-05T09:00:00Z"}, rfcAll(All(r.Iterator(), 0)))
+}
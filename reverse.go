@@ -0,0 +1,225 @@
+package rrule
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrUnbounded is returned when reverse iteration is requested without an
+// upper bound for a pattern that has neither UNTIL nor COUNT.
+var ErrUnbounded = errors.New("pattern has no UNTIL or COUNT, so an upper bound is required to iterate in reverse")
+
+// NewReverseIterator returns an iterator over the occurrences of the pattern in
+// descending order, beginning with the last at or before upper. If upper is
+// zero, iteration begins with the last occurrence of a pattern bounded by UNTIL
+// or COUNT, and ErrUnbounded is returned for a pattern bounded by neither.
+//
+// COUNT is honored by finding the last counted occurrence, which scans
+// forward from Dtstart without retaining the occurrences.
+func (rrule RRule) NewReverseIterator(upper time.Time) (Iterator, error) {
+	it, err := rrule.reverseIterator(upper)
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+func (rrule RRule) reverseIterator(upper time.Time) (*iterator, error) {
+	it, err := rrule.iterator()
+	if err != nil {
+		return nil, err
+	}
+
+	if rrule.Count > 0 {
+		last, ok := lastOccurrence(it)
+		if !ok {
+			return &iterator{descending: true}, nil
+		}
+		if upper.IsZero() || last.Before(upper) {
+			upper = last
+		}
+
+		// start over, now that the count is accounted for by upper.
+		it, _ = rrule.iterator()
+	}
+
+	if upper.IsZero() {
+		if rrule.Until.IsZero() {
+			return nil, ErrUnbounded
+		}
+		upper = it.maxTime
+	}
+
+	it.reverse(upper)
+	return it, nil
+}
+
+// lastOccurrence consumes it and returns the last time it produced.
+func lastOccurrence(it timeSource) (time.Time, bool) {
+	var last time.Time
+	var found bool
+	for next, ok := it.next(); ok; next, ok = it.next() {
+		last, found = next, true
+	}
+	return last, found
+}
+
+// reverse turns a fresh iterator around, so that it returns times in
+// descending order beginning with the last at or before upper.
+func (i *iterator) reverse(upper time.Time) {
+	i.descending = true
+	i.queueCap = 0
+	i.firstPeriod = i.period
+	if upper.Before(i.maxTime) || i.maxTime.IsZero() {
+		i.maxTime = upper
+	}
+
+	if i.periodOf != nil {
+		// begin one period late, because invalid behavior may push the first
+		// instance of a period into the previous one.
+		i.period = i.periodUpTo(i.maxTime) + 1
+	}
+
+	queue := make([]time.Time, 0, len(i.queue))
+	for idx := len(i.queue) - 1; idx >= 0; idx-- {
+		if !i.queue[idx].After(i.maxTime) {
+			queue = append(queue, i.queue[idx])
+		}
+	}
+	i.queue = queue
+
+	// the position of the last time is only known without expanding every
+	// period before it if the pattern is arithmetic.
+	switch {
+	case i.key == nil:
+		i.ordinal = len(queue) - 1
+	case i.arithmetic:
+		i.ordinal = i.countBefore(i.maxTime, true) - 1
+	default:
+		i.lostOrdinal = true
+	}
+}
+
+// trimDescending sorts the variations of a key time into descending order and
+// removes those outside of the minimum and maximum times.
+func (i *iterator) trimDescending(variations []time.Time) []time.Time {
+	slices.SortFunc(variations, func(a, b time.Time) int {
+		return b.Compare(a)
+	})
+
+	for len(variations) > 0 && variations[0].After(i.maxTime) {
+		variations = variations[1:]
+	}
+
+	for idx, v := range variations {
+		if v.Before(i.minTime) {
+			variations = variations[:idx]
+			i.pastMinTime = true
+			break
+		}
+	}
+
+	return variations
+}
+
+// skipBackTo is SkipTo for a reversed iterator. It moves the iterator so that
+// the next time returned is the first at or before t.
+func (i *iterator) skipBackTo(t time.Time) {
+	for len(i.queue) > 0 && i.queue[0].After(t) {
+		i.queue = i.queue[1:]
+		i.ordinal--
+	}
+	for len(i.held) > 0 && i.held[0].After(t) {
+		i.held = i.held[1:]
+	}
+
+	if len(i.queue) > 0 || !t.Before(i.maxTime) {
+		return
+	}
+
+	if i.arithmetic {
+		i.ordinal = i.countBefore(t, true) - 1
+	} else {
+		i.lostOrdinal = true
+	}
+
+	if i.periodOf != nil {
+		if p := i.periodUpTo(t) + 1; p < i.period {
+			i.period = p
+		}
+	}
+
+	i.maxTime = t
+}
+
+// NewReverseIterator returns an iterator over the occurrences of the
+// recurrence in descending order, beginning with the last at or before upper.
+// If upper is zero, every pattern of RRules must be bounded by UNTIL or COUNT,
+// and iteration begins with the last occurrence of the recurrence. Otherwise,
+// an *IteratorError wrapping ErrUnbounded is returned.
+func (r Recurrence) NewReverseIterator(upper time.Time) (Iterator, error) {
+	ri, err := r.reverseIterator(upper)
+	if err != nil {
+		return nil, err
+	}
+	return ri, nil
+}
+
+func (r Recurrence) reverseIterator(upper time.Time) (*recurrenceIterator, error) {
+	if err := r.setDtstart(); err != nil {
+		return nil, err
+	}
+
+	ri := &recurrenceIterator{
+		rrules:      &groupIterator{descending: true},
+		exrules:     &groupIterator{descending: true},
+		descending:  true,
+		lostOrdinal: true,
+	}
+
+	// latest is the last time that could be included, which bounds the
+	// exclusions.
+	var latest time.Time
+
+	for _, rr := range r.RRules {
+		it, err := rr.reverseIterator(upper)
+		if err != nil {
+			return nil, &IteratorError{RRule: rr, Err: err}
+		}
+		if next, ok := it.peek(); ok && next.After(latest) {
+			latest = next
+		}
+		ri.rrules.iters = append(ri.rrules.iters, it)
+	}
+
+	rdates := &iterator{queue: sortedTimes(r.rdates())}
+	if upper.IsZero() {
+		rdates.reverse(absoluteMaxTime)
+	} else {
+		rdates.reverse(upper)
+	}
+	if next, ok := rdates.peek(); ok && next.After(latest) {
+		latest = next
+	}
+	ri.rrules.iters = append(ri.rrules.iters, rdates)
+
+	if latest.IsZero() {
+		// nothing is included, so nothing needs to be excluded.
+		return ri, nil
+	}
+
+	for _, rr := range r.ExRules {
+		it, err := rr.reverseIterator(latest)
+		if err != nil {
+			return nil, &IteratorError{RRule: rr, Err: err}
+		}
+		ri.exrules.iters = append(ri.exrules.iters, it)
+	}
+
+	exdates := &iterator{queue: sortedTimes(r.ExDates)}
+	exdates.reverse(latest)
+	ri.exrules.iters = append(ri.exrules.iters, exdates)
+
+	return ri, nil
+}
@@ -0,0 +1,102 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reversed(tt []time.Time) []time.Time {
+	if len(tt) == 0 {
+		// reverse iterators that find nothing return nil.
+		return nil
+	}
+	r := make([]time.Time, len(tt))
+	for i, t := range tt {
+		r[len(tt)-1-i] = t
+	}
+	return r
+}
+
+func TestReverseIterator(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest || !tc.Terminal {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			forward := All(tc.RRule.Iterator(), 0)
+
+			it, err := tc.RRule.NewReverseIterator(time.Time{})
+			require.NoError(t, err)
+			assert.Equal(t, reversed(forward), All(it, 0))
+
+			if len(forward) > 2 {
+				it, err = tc.RRule.NewReverseIterator(forward[len(forward)-2])
+				require.NoError(t, err)
+				assert.Equal(t, reversed(forward[:len(forward)-1]), All(it, 0))
+			}
+		})
+	}
+}
+
+func TestReverseIteratorUpper(t *testing.T) {
+	for _, str := range windowRRules {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = now
+
+			all := All(rrule.Iterator(), 150)
+
+			it, err := rrule.NewReverseIterator(all[100].Add(time.Nanosecond))
+			require.NoError(t, err)
+			assert.Equal(t, reversed(all[:101]), All(it, 0))
+
+			it, err = rrule.NewReverseIterator(all[120])
+			require.NoError(t, err)
+			assert.Equal(t, all[120], *it.Next())
+			SkipTo(it, all[50].Add(time.Nanosecond))
+			assert.Equal(t, all[50], *it.Next())
+			SkipTo(it, all[60])
+			assert.Equal(t, all[49], *it.Peek())
+		})
+	}
+}
+
+func TestReverseIteratorUnbounded(t *testing.T) {
+	_, err := RRule{Frequency: Daily, Dtstart: now}.NewReverseIterator(time.Time{})
+	assert.Equal(t, ErrUnbounded, err)
+
+	_, err = Recurrence{Dtstart: now, RRules: []RRule{{Frequency: Daily}}}.NewReverseIterator(time.Time{})
+	assert.True(t, errors.Is(err, ErrUnbounded))
+}
+
+func TestRecurrenceReverseIterator(t *testing.T) {
+	for _, tc := range recurrenceCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			forward := All(tc.Recurrence.Iterator(), 0)
+
+			it, err := tc.Recurrence.NewReverseIterator(time.Time{})
+			require.NoError(t, err)
+			assert.Equal(t, reversed(forward), All(it, 0))
+		})
+	}
+
+	r := Recurrence{
+		Dtstart: now,
+		RRules:  []RRule{{Frequency: Daily, ByHours: []int{9, 18}}},
+		RDates:  []time.Time{time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)},
+		ExRules: []RRule{{Frequency: Weekly, Count: 3, ByWeekdays: []QualifiedWeekday{{WD: time.Sunday}}}},
+		ExDates: []time.Time{time.Date(2018, 9, 12, 18, 8, 7, 6, time.UTC)},
+	}
+
+	forward := All(r.Iterator(), 50)
+	upper := forward[len(forward)-1]
+
+	it, err := r.NewReverseIterator(upper)
+	require.NoError(t, err)
+	assert.Equal(t, reversed(forward), All(it, 0))
+}
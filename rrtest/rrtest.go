@@ -0,0 +1,148 @@
+// Package rrtest is a conformance suite for implementations of recurrence
+// rules. It holds every RRULE example of RFC 5545 section 3.8.5.3 and of RFC
+// 7529, with the occurrences the RFCs list for them.
+//
+// The examples are recorded as JSON in the fixtures directory of this
+// package, so that implementations in other languages can run them too. Each
+// file is an array of examples with the fields of Example. Go implementations
+// can run them with Run:
+//
+//	func TestConformance(t *testing.T) {
+//		rrtest.Run(t, func(e rrtest.Example, limit int) ([]time.Time, error) {
+//			...
+//		})
+//	}
+package rrtest
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Example is an example recurrence and the occurrences it produces.
+type Example struct {
+	// Name is the description the RFC gives the example.
+	Name string `json:"name"`
+
+	// Source is the RFC, and its section, that the example is from.
+	Source string `json:"source"`
+
+	// Calendar is the RSCALE of the example, which is GREGORIAN unless the
+	// example is of another calendar from RFC 7529.
+	Calendar string `json:"calendar"`
+
+	// Recurrence holds the iCalendar properties of the example, one per
+	// line, as in "DTSTART;TZID=America/New_York:19970902T090000" followed by
+	// "RRULE:FREQ=DAILY;COUNT=10".
+	Recurrence string `json:"recurrence"`
+
+	// Expected holds the occurrences, in order, in the layout of Layout. If
+	// Unbounded is true, they are only the first of them.
+	Expected  []string `json:"expected"`
+	Unbounded bool     `json:"unbounded,omitempty"`
+
+	// Note explains where Expected differs from what the RFC lists.
+	Note string `json:"note,omitempty"`
+}
+
+// Layout returns the layout of the expected occurrences, which is
+// time.DateOnly for examples of dates, as with DTSTART;VALUE=DATE, and
+// time.RFC3339 otherwise. RFC 3339 times include their UTC offset, so they
+// check the local time of each occurrence as well as its instant.
+func (e Example) Layout() string {
+	if strings.HasPrefix(e.Recurrence, "DTSTART;VALUE=DATE:") {
+		return time.DateOnly
+	}
+	return time.RFC3339
+}
+
+var examples = sync.OnceValues(func() ([]Example, error) {
+	names, err := fs.Glob(fixtures, "fixtures/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Example
+	for _, name := range names {
+		data, err := fixtures.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var ee []Example
+		if err := json.Unmarshal(data, &ee); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		all = append(all, ee...)
+	}
+	return all, nil
+})
+
+// Examples returns every example of the suite.
+func Examples() []Example {
+	ee, err := examples()
+	if err != nil {
+		panic("rrtest: " + err.Error())
+	}
+	return append([]Example(nil), ee...)
+}
+
+// An Implementation returns the first limit occurrences of the recurrence of
+// e. It returns an error wrapping errors.ErrUnsupported if it does not
+// support the example, such as for its calendar, and the example is skipped.
+type Implementation func(e Example, limit int) ([]time.Time, error)
+
+// Run runs every example against impl as a subtest of t, named by its source
+// and name.
+func Run(t *testing.T, impl Implementation) {
+	t.Helper()
+
+	for _, e := range Examples() {
+		t.Run(e.Source+"/"+e.Name, func(t *testing.T) {
+			// one more than expected shows when a bounded example has too
+			// many occurrences.
+			got, err := impl(e, len(e.Expected)+1)
+			if errors.Is(err, errors.ErrUnsupported) {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e.Unbounded && len(got) > len(e.Expected) {
+				got = got[:len(e.Expected)]
+			}
+
+			if err := compare(e, got); err != nil {
+				t.Errorf("%s\n%s", e.Recurrence, err)
+			}
+		})
+	}
+}
+
+// compare returns an error describing the first difference between the
+// occurrences of e and got.
+func compare(e Example, got []time.Time) error {
+	layout := e.Layout()
+	for i, want := range e.Expected {
+		if i >= len(got) {
+			return fmt.Errorf("got %d occurrences, want %d: missing %s", len(got), len(e.Expected), want)
+		}
+		if g := got[i].Format(layout); g != want {
+			return fmt.Errorf("occurrence %d is %s, want %s", i, g, want)
+		}
+	}
+	if len(got) > len(e.Expected) {
+		return fmt.Errorf("got %d occurrences, want %d: extra %s", len(got), len(e.Expected), got[len(e.Expected)].Format(layout))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package rrtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExamples(t *testing.T) {
+	ee := Examples()
+	require.NotEmpty(t, ee)
+
+	names := map[string]bool{}
+	for _, e := range ee {
+		t.Run(e.Name, func(t *testing.T) {
+			assert.False(t, names[e.Source+"/"+e.Name], "duplicate name")
+			names[e.Source+"/"+e.Name] = true
+
+			assert.NotEmpty(t, e.Source)
+			assert.NotEmpty(t, e.Calendar)
+			assert.Contains(t, e.Recurrence, "RRULE:")
+			require.NotEmpty(t, e.Expected)
+
+			// occurrences are in the layout of the example and in order.
+			var last time.Time
+			for _, s := range e.Expected {
+				occurrence, err := time.Parse(e.Layout(), s)
+				require.NoError(t, err)
+				assert.True(t, occurrence.After(last), "%s is out of order", s)
+				last = occurrence
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	e := Example{
+		Recurrence: "DTSTART:19970902T090000Z\nRRULE:FREQ=DAILY;COUNT=2",
+		Expected:   []string{"1997-09-02T09:00:00Z", "1997-09-03T09:00:00Z"},
+	}
+	first := time.Date(1997, time.September, 2, 9, 0, 0, 0, time.UTC)
+	second := first.AddDate(0, 0, 1)
+
+	assert.NoError(t, compare(e, []time.Time{first, second}))
+	assert.EqualError(t, compare(e, []time.Time{first}), "got 1 occurrences, want 2: missing 1997-09-03T09:00:00Z")
+	assert.EqualError(t, compare(e, []time.Time{first, first}), "occurrence 1 is 1997-09-02T09:00:00Z, want 1997-09-03T09:00:00Z")
+	assert.EqualError(t, compare(e, []time.Time{first, second, second}), "got 3 occurrences, want 2: extra 1997-09-03T09:00:00Z")
+
+	// a different offset is a different occurrence, even at the same instant.
+	assert.Error(t, compare(e, []time.Time{first.In(time.FixedZone("EDT", -4*60*60)), second}))
+}
@@ -10,9 +10,9 @@
 package rrule
 
 import (
-	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"time"
 )
 
@@ -24,6 +24,12 @@ type RRule struct {
 	Until         time.Time
 	UntilFloating bool // If true, the RRule will encode using local time (no offset).
 
+	// UntilDate is true if Until is a date rather than a time, as in
+	// UNTIL=20240131. The pattern then includes every occurrence on that
+	// calendar date in the location of Dtstart, and Until is encoded without a
+	// time.
+	UntilDate bool
+
 	Count uint64
 
 	// Dtstart is not actually part of the RRule when
@@ -50,30 +56,77 @@ type RRule struct {
 	// exist, like February 31st.
 	InvalidBehavior InvalidBehavior
 
+	// NonexistentBehavior and AmbiguousBehavior define how to place
+	// occurrences at local times that do not exist or occur twice, because
+	// clocks change in the location of Dtstart. Patterns are expanded in
+	// local time, so that BYHOUR=9 is 09:00 even on days clocks change. Like
+	// Dtstart, they are not part of the encoded RRule.
+	NonexistentBehavior NonexistentBehavior
+	AmbiguousBehavior   AmbiguousBehavior
+
 	WeekStart *time.Weekday // if nil, Monday
 }
 
-// Validate checks that the pattern is valid.
+// Validate checks that the pattern is valid. If it is not, the error returned
+// is a *ValidationError listing every problem found.
 func (rrule RRule) Validate() error {
-	if rrule.Frequency != Yearly && rrule.Frequency != Monthly {
-		for _, wd := range rrule.ByWeekdays {
-			if wd.N != 0 {
-				return errors.New("BYDAY entries may only specify a numeric component when the frequency is YEARLY or MONTHLY")
-			}
-		}
+	v := &ValidationError{}
+
+	if _, err := freqToStr(rrule.Frequency); err != nil {
+		v.add("FREQ", strconv.Itoa(int(rrule.Frequency)), "FREQ must be one of SECONDLY, MINUTELY, HOURLY, DAILY, WEEKLY, MONTHLY, or YEARLY")
+		v.Problems[len(v.Problems)-1].Err = err
 	}
-	if rrule.Frequency == Yearly && len(rrule.ByWeekNumbers) > 0 {
-		for _, wd := range rrule.ByWeekdays {
-			if wd.N != 0 {
-				return errors.New("BYDAY entries must not specify a numeric component when the frequency is YEARLY and a BYWEEKNO rule is present")
-			}
+
+	if rrule.Count != 0 && !rrule.Until.IsZero() {
+		v.add("COUNT/UNTIL", fmt.Sprintf("COUNT=%d;UNTIL=%s", rrule.Count, rrule.Until.Format(rfc5545WithOffset)), "COUNT and UNTIL must not appear in the same RRULE")
+	}
+
+	if rrule.Interval < 0 {
+		v.add("INTERVAL", strconv.Itoa(rrule.Interval), "INTERVAL must be a positive integer")
+	}
+
+	v.checkRange("BYSECOND", rrule.BySeconds, 0, 60, true)
+	v.checkRange("BYMINUTE", rrule.ByMinutes, 0, 59, true)
+	v.checkRange("BYHOUR", rrule.ByHours, 0, 23, true)
+
+	for _, wd := range rrule.ByWeekdays {
+		switch {
+		case wd.WD < time.Sunday || wd.WD > time.Saturday:
+			v.add("BYDAY", strconv.Itoa(int(wd.WD)), "BYDAY entries must name a day of the week")
+		case wd.N < -53 || wd.N > 53:
+			v.add("BYDAY", qualifiedWeekdayString(wd), "BYDAY numeric components must be between [-53,-1] or [1,53]")
+		case wd.N != 0 && rrule.Frequency != Yearly && rrule.Frequency != Monthly:
+			v.add("BYDAY", qualifiedWeekdayString(wd), "BYDAY entries may only specify a numeric component when the frequency is YEARLY or MONTHLY")
+		case wd.N != 0 && rrule.Frequency == Yearly && len(rrule.ByWeekNumbers) > 0:
+			v.add("BYDAY", qualifiedWeekdayString(wd), "BYDAY entries must not specify a numeric component when the frequency is YEARLY and a BYWEEKNO rule is present")
 		}
 	}
 
+	v.checkRange("BYMONTHDAY", rrule.ByMonthDays, -31, 31, false)
 	if rrule.Frequency == Weekly && len(rrule.ByMonthDays) > 0 {
-		return errors.New("WEEKLY recurrences must not include BYMONTHDAY")
+		v.add("BYMONTHDAY", intlist(rrule.ByMonthDays), "WEEKLY recurrences must not include BYMONTHDAY")
 	}
 
+	v.checkRange("BYYEARDAY", rrule.ByYearDays, -366, 366, false)
+	switch rrule.Frequency {
+	case Daily, Weekly, Monthly:
+		if len(rrule.ByYearDays) > 0 {
+			v.add("BYYEARDAY", intlist(rrule.ByYearDays), "DAILY, WEEKLY, and MONTHLY recurrences must not include BYYEARDAY")
+		}
+	}
+
+	v.checkRange("BYWEEKNO", rrule.ByWeekNumbers, -53, 53, false)
+	if rrule.Frequency != Yearly && len(rrule.ByWeekNumbers) > 0 {
+		v.add("BYWEEKNO", intlist(rrule.ByWeekNumbers), "BYWEEKNO may only be used when the frequency is YEARLY")
+	}
+
+	for _, m := range rrule.ByMonths {
+		if m < time.January || m > time.December {
+			v.add("BYMONTH", strconv.Itoa(int(m)), "BYMONTH values must be between [1,12]")
+		}
+	}
+
+	v.checkRange("BYSETPOS", rrule.BySetPos, -366, 366, false)
 	if len(rrule.BySetPos) != 0 {
 		if len(rrule.BySeconds) == 0 &&
 			len(rrule.ByMinutes) == 0 &&
@@ -83,140 +136,154 @@ func (rrule RRule) Validate() error {
 			len(rrule.ByWeekNumbers) == 0 &&
 			len(rrule.ByMonths) == 0 &&
 			len(rrule.ByYearDays) == 0 {
-			return errors.New("BYSETPOS rules must be used in conjunction with at least one other BYXXX rule part")
+			v.add("BYSETPOS", intlist(rrule.BySetPos), "BYSETPOS rules must be used in conjunction with at least one other BYXXX rule part")
 		}
 	}
 
-	if rrule.Count != 0 && !rrule.Until.IsZero() {
-		return errors.New("COUNT and UNTIL must not appear in the same RRULE")
+	if rrule.WeekStart != nil && (*rrule.WeekStart < time.Sunday || *rrule.WeekStart > time.Saturday) {
+		v.add("WKST", strconv.Itoa(int(*rrule.WeekStart)), "WKST must name a day of the week")
 	}
 
-	for _, sp := range rrule.BySetPos {
-		if sp == 0 || sp < -366 || sp > 366 {
-			return errors.New("BYSETPOS values must be between [-366,-1] or [1,366]")
-		}
+	if len(v.Problems) == 0 {
+		return nil
 	}
-
-	return nil
+	return v
 }
 
 // Iterator returns an Iterator for the pattern. The pattern must be valid or Iterator will panic.
 func (rrule RRule) Iterator() Iterator {
-	err := rrule.Validate()
+	it, err := rrule.NewIterator()
 	if err != nil {
 		panic(err)
 	}
+	return it
+}
+
+// NewIterator returns an Iterator for the pattern, or an error if the pattern
+// is invalid.
+func (rrule RRule) NewIterator() (Iterator, error) {
+	it, err := rrule.iterator()
+	if err != nil {
+		return nil, err
+	}
+	return it, nil
+}
 
+// NewTimeIterator returns a TimeIterator for the pattern, or an error if the
+// pattern is invalid.
+func (rrule RRule) NewTimeIterator() (TimeIterator, error) {
+	it, err := rrule.iterator()
+	if err != nil {
+		return nil, err
+	}
+	return timeIterator{it}, nil
+}
+
+func (rrule RRule) iterator() (*iterator, error) {
+	err := rrule.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	start := rrule.Dtstart
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	// periods are expanded in the wall-clock time of start, as if in UTC, and
+	// their times are placed in its location once expanded.
+	wall := rrule
+	wall.Dtstart = wallClock(start)
+
+	// Validate has checked that the frequency is one of these.
+	var it *iterator
 	switch rrule.Frequency {
 	case Secondly:
-		return setSecondly(rrule)
+		it = setSecondly(wall)
 	case Minutely:
-		return setMinutely(rrule)
+		it = setMinutely(wall)
 	case Hourly:
-		return setHourly(rrule)
+		it = setHourly(wall)
 	case Daily:
-		return setDaily(rrule)
+		it = setDaily(wall)
 	case Weekly:
-		return setWeekly(rrule)
+		it = setWeekly(wall)
 	case Monthly:
-		return setMonthly(rrule)
+		it = setMonthly(wall)
 	case Yearly:
-		return setYearly(rrule)
-	default:
-		panic(fmt.Sprintf("invalid frequency %v", rrule.Frequency))
+		it = setYearly(wall)
+	}
+
+	it.minTime = start
+	it.maxTime = rrule.until(start.Location())
+	it.place(rrule, start)
+
+	// if BYSETPOS selects nothing from any period, no period needs to be
+	// expanded, and iteration would otherwise never end.
+	if len(rrule.BySetPos) > 0 && !selectsFrom(rrule.BySetPos, rrule.maxPeriodSize()) {
+		it.pastMaxTime = true
 	}
+
+	it.arithmetic = rrule.arithmetic(start)
+	return it, nil
 }
 
 func setSecondly(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
-
-	nextFn := func() *time.Time {
-		ret := current // copy current
-		current = current.Add(time.Duration(interval) * time.Second)
-		return &ret
-	}
+	key, periodOf := fixedPeriods(start, 1, interval)
 
 	// An rrule with Interval of 1 and BySeconds will potentially cycle through
 	// many seconds that get skipped. This is a fairly expensive case, but can be
-	// short-circuited by skipping to each subsequent BySeconds point instead of
-	// each second.
+	// short-circuited by numbering only the BySeconds points of each minute as
+	// periods, instead of every second.
 	if interval == 1 && len(rrule.BySeconds) > 0 {
+		seen := map[int]bool{}
 		seconds := []int{}
 		for _, s := range rrule.BySeconds {
 			if s < 0 {
 				s += 60
 			}
-			seconds = append(seconds, s)
+			if !seen[s] {
+				seconds = append(seconds, s)
+			}
+			seen[s] = true
 		}
 
 		sort.Ints(seconds)
-		initialSecond := start.Second()
-		loopIdx := 0
-		wentPastInitial := false
-		var firstDiff time.Duration
-
-		var secondsLooper []time.Duration
-		for i, s := range seconds {
-			if !wentPastInitial && s > initialSecond {
-				wentPastInitial = true
-				loopIdx = i
-				firstDiff = time.Duration(s-initialSecond) * time.Second
-			}
+		perMinute := len(seconds)
+		origin := addSeconds(start, -start.Second())
 
-			nextIdx := i + 1
-			if nextIdx == len(seconds) {
-				secondsLooper = append(secondsLooper, time.Duration(60+seconds[0]-seconds[i])*time.Second)
-			} else {
-				secondsLooper = append(secondsLooper, time.Duration(seconds[nextIdx]-seconds[i])*time.Second)
-			}
+		key = func(n int) (time.Time, bool) {
+			minutes := floorDiv(n, perMinute)
+			return addSeconds(origin, minutes*60+seconds[floorMod(n, perMinute)]), true
 		}
 
-		if !wentPastInitial {
-			// all the BySecond terms are lower numbers than the start time second, so we need to wrap around for the first diff
-			firstDiff = time.Duration(seconds[0]+60-initialSecond) * time.Second
-		}
-
-		secondsLooperFn := func() *time.Time {
-			ret := current // copy
-			current = current.Add(secondsLooper[loopIdx])
-			loopIdx++
-			if loopIdx >= len(secondsLooper) {
-				loopIdx = 0
-			}
-			return &ret
-		}
+		wholeOrigin := time.Unix(origin.Unix(), 0)
 
-		var afterFirst bool
+		periodOf = func(t time.Time) int {
+			elapsed := secondsBetween(wholeOrigin, t)
+			minutes := floorDiv(elapsed, 60)
 
-		// return an initial function that does the first initial
-		nextFn = func() *time.Time {
-			if afterFirst {
-				return secondsLooperFn()
-			}
-
-			ret := current // copy
-			current = current.Add(firstDiff)
-			afterFirst = true
-			return &ret
+			// find the last BySeconds point at or before t. if there is none,
+			// the index becomes -1, which numbers the last point of the
+			// previous minute.
+			idx := sort.SearchInts(seconds, elapsed-minutes*60+1) - 1
+			return minutes*perMinute + idx
 		}
 	}
 
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		queueCap: rrule.Count,
 		setpos:   rrule.BySetPos,
-		next:     nextFn,
+		period:   periodOf(start),
+		key:      key,
+		periodOf: periodOf,
 
 		valid: combineLimiters(
 			validSecond(rrule.BySeconds),
@@ -225,46 +292,36 @@ func setSecondly(rrule RRule) *iterator {
 			validWeekday(rrule.ByWeekdays),
 			validMonthDay(rrule.ByMonthDays),
 			validMonth(rrule.ByMonths),
-			validWeek(rrule.ByWeekNumbers),
+			validWeek(rrule.ByWeekNumbers, rrule.weekStart()),
 			validYearDay(rrule.ByYearDays),
 		),
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			return []time.Time{*t}
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			return e.tt
 		},
 	}
 }
 
 func setMinutely(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
+	key, periodOf := fixedPeriods(start, 60, interval)
 
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-			current = current.Add(time.Duration(interval) * time.Minute)
-			return &ret
-		},
+		key:      key,
+		periodOf: periodOf,
 
 		valid: combineLimiters(
 			validMonth(rrule.ByMonths),
-			validWeek(rrule.ByWeekNumbers),
+			validWeek(rrule.ByWeekNumbers, rrule.weekStart()),
 			validYearDay(rrule.ByYearDays),
 			validMonthDay(rrule.ByMonthDays),
 			validWeekday(rrule.ByWeekdays),
@@ -272,170 +329,130 @@ func setMinutely(rrule RRule) *iterator {
 			validMinute(rrule.ByMinutes),
 		),
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			tt := expandBySeconds([]time.Time{*t}, rrule.BySeconds...)
-			tt = limitBySetPos(tt, rrule.BySetPos)
-			return tt
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			e.expandBySeconds(rrule.BySeconds...)
+			return e.tt
 		},
 	}
 }
 
 func setHourly(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
+	key, periodOf := fixedPeriods(start, 60*60, interval)
 
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-			current = current.Add(time.Duration(interval) * time.Hour)
-			return &ret
-		},
+		key:      key,
+		periodOf: periodOf,
 
 		valid: combineLimiters(
 			validMonth(rrule.ByMonths),
-			validWeek(rrule.ByWeekNumbers),
+			validWeek(rrule.ByWeekNumbers, rrule.weekStart()),
 			validYearDay(rrule.ByYearDays),
 			validMonthDay(rrule.ByMonthDays),
 			validWeekday(rrule.ByWeekdays),
 			validHour(rrule.ByHours),
 		),
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			tt := expandByMinutes([]time.Time{*t}, rrule.ByMinutes...)
-			tt = expandBySeconds(tt, rrule.BySeconds...)
-			tt = limitBySetPos(tt, rrule.BySetPos)
-			return tt
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			e.expandByMinutes(rrule.ByMinutes...)
+			e.expandBySeconds(rrule.BySeconds...)
+			return e.tt
 		},
 	}
 }
 
 func setMonthly(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
-
-	current := start
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	checkLeapDay := current.Day() >= 29
+	// when BYMONTHDAY or BYDAY choose the days of each month, the day of
+	// the key is irrelevant, and the month need not have the day of start.
+	day := start.Day()
+	if len(rrule.ByMonthDays) > 0 || len(rrule.ByWeekdays) > 0 {
+		day = 1
+	}
 
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-
-			current = current.AddDate(0, interval, 0)
+		key: func(n int) (time.Time, bool) {
+			month := start.Month() + time.Month(n*interval)
+			t := time.Date(start.Year(), month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
 
-			// check that we advanced the correct
-			// number of months, e.g. if we meant to hit
-			// a feb 29th, but it's not a leap year.
+			// check that we landed in the correct month, e.g. if we meant
+			// to hit a feb 29th, but it's not a leap year.
 			//
-			// because we only support gregorian, we only
-			// need this logic on rules that key on the 29th,
-			// 30th, or 31st of a month
-			if checkLeapDay {
-				diff := monthDiff(ret, current)
-				if diff%interval != 0 {
-					switch rrule.InvalidBehavior {
-					case PrevInvalid:
-						current = current.AddDate(0, 0, -1)
-					case NextInvalid:
-						// time.AddDate already behaves this way.
-					case OmitInvalid:
-						mult := 1
-						for diff%interval != 0 {
-							mult++
-							current = ret.AddDate(0, interval*mult, 0)
-							diff = monthDiff(ret, current)
-						}
-					}
+			// because we only support gregorian, this can only happen on
+			// rules that key on the 29th, 30th, or 31st of a month
+			if t.Day() != day {
+				switch rrule.InvalidBehavior {
+				case PrevInvalid:
+					t = time.Date(start.Year(), month+1, 0, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+				case NextInvalid:
+					t = time.Date(start.Year(), month+1, 1, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+				case OmitInvalid:
+					return time.Time{}, false
 				}
 			}
 
-			return &ret
+			return t, true
 		},
-
-		valid: func(t *time.Time) bool {
-			if t == nil {
-				return false
-			}
-			if len(rrule.ByMonthDays) > 0 {
-				return checkLimiters(t,
-					validMonth(rrule.ByMonths),
-					validWeekday(rrule.ByWeekdays),
-				)
-			}
-			return checkLimiters(t,
-				validMonth(rrule.ByMonths),
-			)
+		periodOf: func(t time.Time) int {
+			return floorDiv(monthDiff(start, t.In(start.Location())), interval)
 		},
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			tt := expandBySeconds([]time.Time{*t}, rrule.BySeconds...)
-			tt = expandByMinutes(tt, rrule.ByMinutes...)
-			tt = expandByHours(tt, rrule.ByHours...)
+		valid: combineLimiters(
+			validMonth(rrule.ByMonths),
+		),
+
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			e.expandBySeconds(rrule.BySeconds...)
+			e.expandByMinutes(rrule.ByMinutes...)
+			e.expandByHours(rrule.ByHours...)
+
 			if len(rrule.ByMonthDays) > 0 {
-				tt = expandByMonthDays(tt, rrule.ByMonthDays...)
+				// BYDAY limits BYMONTHDAY, as it does for YEARLY.
+				e.expandByMonthDays(rrule.InvalidBehavior, nil, rrule.ByMonthDays...)
+				e.limit(validWeekday(rrule.ByWeekdays))
 			} else if len(rrule.ByWeekdays) > 0 {
-				tt = expandMonthByWeekdays(tt, rrule.InvalidBehavior, rrule.BySetPos, rrule.ByWeekdays...)
+				e.expandMonthByWeekdays(rrule.InvalidBehavior, rrule.ByWeekdays...)
 			}
-			return tt
+			return e.tt
 		},
 	}
 }
 
 func setDaily(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
-
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-			current = current.AddDate(0, 0, interval)
-			return &ret
+		key: func(n int) (time.Time, bool) {
+			return start.AddDate(0, 0, n*interval), true
+		},
+		periodOf: func(t time.Time) int {
+			return floorDiv(daysBetween(start, t), interval)
 		},
 
 		valid: combineLimiters(
@@ -444,131 +461,122 @@ func setDaily(rrule RRule) *iterator {
 			validWeekday(rrule.ByWeekdays),
 		),
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			tt := expandBySeconds([]time.Time{*t}, rrule.BySeconds...)
-			tt = expandByMinutes(tt, rrule.ByMinutes...)
-			tt = expandByHours(tt, rrule.ByHours...)
-			tt = limitBySetPos(tt, rrule.BySetPos)
-			return tt
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			e.expandBySeconds(rrule.BySeconds...)
+			e.expandByMinutes(rrule.ByMinutes...)
+			e.expandByHours(rrule.ByHours...)
+			return e.tt
 		},
 	}
 }
 
 func setWeekly(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
+	firstWeek := backToWeekday(start, rrule.weekStart())
 
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-			current = current.AddDate(0, 0, interval*7)
-			return &ret
+		key: func(n int) (time.Time, bool) {
+			return start.AddDate(0, 0, n*interval*7), true
+		},
+		periodOf: func(t time.Time) int {
+			return floorDiv(floorDiv(daysBetween(firstWeek, t), 7), interval)
 		},
 
 		valid: combineLimiters(
 			validMonth(rrule.ByMonths),
 		),
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
-			}
-			tt := expandBySeconds([]time.Time{*t}, rrule.BySeconds...)
-			tt = expandByMinutes(tt, rrule.ByMinutes...)
-			tt = expandByHours(tt, rrule.ByHours...)
-			tt = limitBySetPos(tt, rrule.BySetPos)
-			tt = expandByWeekdays(tt, rrule.weekStart(), rrule.ByWeekdays...)
-			return tt
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
+			e.expandBySeconds(rrule.BySeconds...)
+			e.expandByMinutes(rrule.ByMinutes...)
+			e.expandByHours(rrule.ByHours...)
+			e.expandByWeekdays(rrule.weekStart(), rrule.ByWeekdays...)
+			return e.tt
 		},
 	}
 }
 
 func setYearly(rrule RRule) *iterator {
 	start := rrule.Dtstart
-	if start.IsZero() {
-		start = time.Now()
-	}
 
 	interval := 1
 	if rrule.Interval != 0 {
 		interval = rrule.Interval
 	}
 
-	current := start
-
 	plainByDay := plainWeekdays(rrule.ByWeekdays)
 
+	// without BYYEARDAY, BYMONTHDAY, or BYMONTH, BYWEEKNO expands to the weeks
+	// of each year, which may begin and end in the neighboring years.
+	byWeeks := len(rrule.ByWeekNumbers) != 0 &&
+		len(rrule.ByYearDays) == 0 &&
+		len(rrule.ByMonthDays) == 0 &&
+		len(rrule.ByMonths) == 0
+
 	return &iterator{
-		minTime:  start,
-		maxTime:  timeOrMax(rrule.Until),
 		setpos:   rrule.BySetPos,
 		queueCap: rrule.Count,
-		next: func() *time.Time {
-			ret := current // copy current
-			current = current.AddDate(interval, 0, 0)
-			return &ret
+		key: func(n int) (time.Time, bool) {
+			return start.AddDate(n*interval, 0, 0), true
 		},
-
-		valid: func(t *time.Time) bool {
-			if t == nil {
-				return false
+		periodOf: func(t time.Time) int {
+			t = t.In(start.Location())
+			year := t.Year()
+			if byWeeks {
+				year, _, _ = weekNumber(t, rrule.weekStart())
 			}
+			return floorDiv(year-start.Year(), interval)
+		},
 
-			// see note 2 on page 44 of RFC 5545, including erratum 3747.
-			if len(rrule.ByYearDays) > 0 || len(rrule.ByMonthDays) > 0 {
-				return checkLimiters(t,
-					validMonth(rrule.ByMonths),
-					validWeekday(rrule.ByWeekdays),
-				)
-			}
+		// the days of each year are expanded, so the key is always valid.
+		valid: alwaysValid,
 
-			return checkLimiters(t,
-				validMonth(rrule.ByMonths),
-			)
-		},
+		variations: func(e *expansion, t time.Time) []time.Time {
+			e.reset(t)
 
-		variations: func(t *time.Time) []time.Time {
-			if t == nil {
-				return nil
+			e.expandBySeconds(rrule.BySeconds...)
+			e.expandByMinutes(rrule.ByMinutes...)
+			e.expandByHours(rrule.ByHours...)
+
+			// see note 2 on page 44 of RFC 5545, including errata 3747 and
+			// 3779: BYDAY limits BYYEARDAY and BYMONTHDAY, and otherwise
+			// expands within BYMONTH, BYWEEKNO, or the year.
+			switch {
+			case len(rrule.ByYearDays) != 0:
+				e.expandByYearDays(rrule.InvalidBehavior, rrule.ByYearDays...)
+				e.limit(combineLimiters(
+					validMonth(rrule.ByMonths),
+					validMonthDay(rrule.ByMonthDays),
+					validWeekday(rrule.ByWeekdays),
+				))
+			case len(rrule.ByMonthDays) != 0:
+				e.expandByMonthDays(rrule.InvalidBehavior, rrule.ByMonths, rrule.ByMonthDays...)
+				e.limit(validWeekday(rrule.ByWeekdays))
+			case len(rrule.ByMonths) != 0:
+				e.expandByMonths(rrule.InvalidBehavior, rrule.ByMonths...)
+				e.expandMonthByWeekdays(rrule.InvalidBehavior, rrule.ByWeekdays...)
+			case byWeeks:
+				e.expandByWeekNumbers(rrule.InvalidBehavior, rrule.weekStart(), plainByDay, rrule.ByWeekNumbers...)
+			default:
+				e.expandYearByWeekdays(rrule.InvalidBehavior, rrule.ByWeekdays...)
 			}
 
-			tt := expandBySeconds([]time.Time{*t}, rrule.BySeconds...)
-			tt = expandByMinutes(tt, rrule.ByMinutes...)
-			tt = expandByHours(tt, rrule.ByHours...)
-
-			tt = expandByMonthDays(tt, rrule.ByMonthDays...)
-			tt = expandByYearDays(tt, rrule.InvalidBehavior, rrule.ByYearDays...)
-			tt = expandByMonths(tt, rrule.InvalidBehavior, rrule.ByMonths...)
-
-			// see note 2 on page 44 of RFC 5545, including erratum 3779.
-			if len(rrule.ByYearDays) == 0 && len(rrule.ByMonthDays) == 0 {
-				if len(rrule.ByMonths) != 0 {
-					tt = expandMonthByWeekdays(tt, rrule.InvalidBehavior, nil, rrule.ByWeekdays...)
-				} else if len(rrule.ByWeekNumbers) != 0 {
-					tt = expandByWeekNumbers(tt, rrule.InvalidBehavior, rrule.weekStart(), plainByDay, rrule.ByWeekNumbers...)
-				} else {
-					tt = expandYearByWeekdays(tt, rrule.InvalidBehavior, rrule.ByWeekdays...)
-				}
+			// when BYWEEKNO was not expanded, it limits the days that were.
+			if len(rrule.ByWeekNumbers) != 0 && !byWeeks {
+				e.limit(validWeek(rrule.ByWeekNumbers, rrule.weekStart()))
 			}
 
-			tt = limitBySetPos(tt, rrule.BySetPos)
-			return tt
+			return e.tt
 		},
 	}
 }
@@ -580,6 +588,15 @@ func (rrule *RRule) weekStart() time.Weekday {
 	return *rrule.WeekStart
 }
 
+// until returns the latest time the pattern may produce, for a pattern in loc.
+func (rrule RRule) until(loc *time.Location) time.Time {
+	if rrule.UntilDate && !rrule.Until.IsZero() {
+		y, m, d := rrule.Until.Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+	return timeOrMax(rrule.Until)
+}
+
 func timeOrMax(t time.Time) time.Time {
 	if t.IsZero() {
 		return absoluteMaxTime
@@ -1,6 +1,7 @@
 package rrule
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -311,6 +312,296 @@ var cases = []struct {
 		NoTeambitionComparison: true,
 	},
 
+	{
+		Name:   "byhour across spring forward",
+		String: "FREQ=DAILY;COUNT=3;BYMINUTE=0;BYHOUR=9",
+		RRule: RRule{
+			Frequency: Daily,
+			Count:     3,
+			Dtstart:   time.Date(2007, time.March, 10, 0, 0, 0, 0, NewYork()),
+			ByHours:   []int{9},
+			ByMinutes: []int{0},
+		},
+		Dates:    []string{"2007-03-10T09:00:00-05:00", "2007-03-11T09:00:00-04:00", "2007-03-12T09:00:00-04:00"},
+		Terminal: true,
+	},
+
+	{
+		Name:   "byhour across fall back",
+		String: "FREQ=DAILY;COUNT=3;BYMINUTE=0;BYHOUR=9",
+		RRule: RRule{
+			Frequency: Daily,
+			Count:     3,
+			Dtstart:   time.Date(2007, time.November, 3, 0, 0, 0, 0, NewYork()),
+			ByHours:   []int{9},
+			ByMinutes: []int{0},
+		},
+		Dates:    []string{"2007-11-03T09:00:00-04:00", "2007-11-04T09:00:00-05:00", "2007-11-05T09:00:00-05:00"},
+		Terminal: true,
+	},
+
+	{
+		Name: "byhour nonexistent with offset",
+		RRule: RRule{
+			Frequency: Daily,
+			Count:     3,
+			Dtstart:   time.Date(2007, time.March, 10, 0, 0, 0, 0, NewYork()),
+			ByHours:   []int{2},
+			ByMinutes: []int{30},
+		},
+		Dates:    []string{"2007-03-10T02:30:00-05:00", "2007-03-11T03:30:00-04:00", "2007-03-12T02:30:00-04:00"},
+		Terminal: true,
+
+		// teambition leaves nonexistent times to time.Date.
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "byhour nonexistent with shift",
+		RRule: RRule{
+			Frequency:           Daily,
+			Count:               3,
+			Dtstart:             time.Date(2007, time.March, 10, 0, 0, 0, 0, NewYork()),
+			ByHours:             []int{2},
+			ByMinutes:           []int{30},
+			NonexistentBehavior: ShiftNonexistent,
+		},
+		Dates:                  []string{"2007-03-10T02:30:00-05:00", "2007-03-11T03:00:00-04:00", "2007-03-12T02:30:00-04:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "byhour nonexistent with skip",
+		RRule: RRule{
+			Frequency:           Daily,
+			Count:               3,
+			Dtstart:             time.Date(2007, time.March, 10, 0, 0, 0, 0, NewYork()),
+			ByHours:             []int{2},
+			ByMinutes:           []int{30},
+			NonexistentBehavior: SkipNonexistent,
+		},
+		Dates:                  []string{"2007-03-10T02:30:00-05:00", "2007-03-12T02:30:00-04:00", "2007-03-13T02:30:00-04:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "byhour ambiguous with first",
+		RRule: RRule{
+			Frequency: Daily,
+			Count:     3,
+			Dtstart:   time.Date(2007, time.November, 3, 0, 0, 0, 0, NewYork()),
+			ByHours:   []int{1},
+			ByMinutes: []int{30},
+		},
+		Dates:    []string{"2007-11-03T01:30:00-04:00", "2007-11-04T01:30:00-04:00", "2007-11-05T01:30:00-05:00"},
+		Terminal: true,
+	},
+
+	{
+		Name: "byhour ambiguous with second",
+		RRule: RRule{
+			Frequency:         Daily,
+			Count:             3,
+			Dtstart:           time.Date(2007, time.November, 3, 0, 0, 0, 0, NewYork()),
+			ByHours:           []int{1},
+			ByMinutes:         []int{30},
+			AmbiguousBehavior: SecondAmbiguous,
+		},
+		Dates:                  []string{"2007-11-03T01:30:00-04:00", "2007-11-04T01:30:00-05:00", "2007-11-05T01:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "byhour ambiguous with both",
+		RRule: RRule{
+			Frequency:         Daily,
+			Count:             3,
+			Dtstart:           time.Date(2007, time.November, 3, 0, 0, 0, 0, NewYork()),
+			ByHours:           []int{1},
+			ByMinutes:         []int{30},
+			AmbiguousBehavior: BothAmbiguous,
+		},
+		Dates:                  []string{"2007-11-03T01:30:00-04:00", "2007-11-04T01:30:00-04:00", "2007-11-04T01:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly across spring forward",
+		RRule: RRule{
+			Frequency: Hourly,
+			Count:     4,
+			Dtstart:   time.Date(2007, time.March, 11, 0, 30, 0, 0, NewYork()),
+		},
+		Dates:                  []string{"2007-03-11T00:30:00-05:00", "2007-03-11T01:30:00-05:00", "2007-03-11T03:30:00-04:00", "2007-03-11T04:30:00-04:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly across spring forward with shift",
+		RRule: RRule{
+			Frequency:           Hourly,
+			Count:               4,
+			Dtstart:             time.Date(2007, time.March, 11, 0, 30, 0, 0, NewYork()),
+			NonexistentBehavior: ShiftNonexistent,
+		},
+		Dates:                  []string{"2007-03-11T00:30:00-05:00", "2007-03-11T01:30:00-05:00", "2007-03-11T03:00:00-04:00", "2007-03-11T03:30:00-04:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly across fall back",
+		RRule: RRule{
+			Frequency: Hourly,
+			Count:     4,
+			Dtstart:   time.Date(2007, time.November, 4, 0, 30, 0, 0, NewYork()),
+		},
+		Dates:                  []string{"2007-11-04T00:30:00-04:00", "2007-11-04T01:30:00-04:00", "2007-11-04T01:30:00-05:00", "2007-11-04T02:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly across fall back with first",
+		RRule: RRule{
+			Frequency:         Hourly,
+			Count:             4,
+			Dtstart:           time.Date(2007, time.November, 4, 0, 30, 0, 0, NewYork()),
+			AmbiguousBehavior: FirstAmbiguous,
+		},
+		Dates:                  []string{"2007-11-04T00:30:00-04:00", "2007-11-04T01:30:00-04:00", "2007-11-04T02:30:00-05:00", "2007-11-04T03:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly across fall back with second",
+		RRule: RRule{
+			Frequency:         Hourly,
+			Count:             4,
+			Dtstart:           time.Date(2007, time.November, 4, 0, 30, 0, 0, NewYork()),
+			AmbiguousBehavior: SecondAmbiguous,
+		},
+		Dates:                  []string{"2007-11-04T00:30:00-04:00", "2007-11-04T01:30:00-05:00", "2007-11-04T02:30:00-05:00", "2007-11-04T03:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "minutely across spring forward",
+		RRule: RRule{
+			Frequency: Minutely,
+			Interval:  20,
+			Count:     6,
+			Dtstart:   time.Date(2007, time.March, 11, 1, 20, 0, 0, NewYork()),
+		},
+		Dates:                  []string{"2007-03-11T01:20:00-05:00", "2007-03-11T01:40:00-05:00", "2007-03-11T03:00:00-04:00", "2007-03-11T03:20:00-04:00", "2007-03-11T03:40:00-04:00", "2007-03-11T04:00:00-04:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "minutely across fall back",
+		RRule: RRule{
+			Frequency: Minutely,
+			Interval:  20,
+			Count:     5,
+			Dtstart:   time.Date(2007, time.November, 4, 0, 40, 0, 0, NewYork()),
+		},
+		Dates:                  []string{"2007-11-04T00:40:00-04:00", "2007-11-04T01:00:00-04:00", "2007-11-04T01:20:00-04:00", "2007-11-04T01:40:00-04:00", "2007-11-04T01:00:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly bysetpos first across fall back",
+		RRule: RRule{
+			Frequency: Hourly,
+			Count:     4,
+			Dtstart:   time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork()),
+			ByMinutes: []int{0, 30},
+			BySetPos:  []int{1},
+		},
+		Dates:                  []string{"2018-11-04T00:00:00-04:00", "2018-11-04T01:00:00-04:00", "2018-11-04T01:00:00-05:00", "2018-11-04T02:00:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly bysetpos last across fall back",
+		RRule: RRule{
+			Frequency: Hourly,
+			Count:     4,
+			Dtstart:   time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork()),
+			ByMinutes: []int{0, 30},
+			BySetPos:  []int{-1},
+		},
+		Dates:                  []string{"2018-11-04T00:30:00-04:00", "2018-11-04T01:30:00-04:00", "2018-11-04T01:30:00-05:00", "2018-11-04T02:30:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "hourly bysetpos second across fall back",
+		RRule: RRule{
+			Frequency: Hourly,
+			Until:     time.Date(2018, time.November, 4, 12, 0, 0, 0, time.UTC),
+			Dtstart:   time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork()),
+			ByMinutes: []int{30},
+			BySetPos:  []int{2},
+		},
+		Dates:                  []string{},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "secondly bysetpos second across fall back",
+		RRule: RRule{
+			Frequency: Secondly,
+			Until:     time.Date(2018, time.November, 4, 7, 0, 0, 0, time.UTC),
+			Dtstart:   time.Date(2018, time.November, 4, 0, 0, 0, 0, NewYork()),
+			BySeconds: []int{1},
+			BySetPos:  []int{2},
+		},
+		Dates:                  []string{},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "minutely across fall back with both",
+		RRule: RRule{
+			Frequency:         Minutely,
+			Interval:          20,
+			Count:             7,
+			Dtstart:           time.Date(2007, time.November, 4, 1, 0, 0, 0, NewYork()),
+			AmbiguousBehavior: BothAmbiguous,
+		},
+		Dates:                  []string{"2007-11-04T01:00:00-04:00", "2007-11-04T01:20:00-04:00", "2007-11-04T01:40:00-04:00", "2007-11-04T01:00:00-05:00", "2007-11-04T01:20:00-05:00", "2007-11-04T01:40:00-05:00", "2007-11-04T02:00:00-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "secondly byseconds across fall back with both",
+		RRule: RRule{
+			Frequency:         Secondly,
+			Until:             time.Date(2007, time.November, 4, 6, 0, 30, 0, time.UTC),
+			Dtstart:           time.Date(2007, time.November, 4, 1, 59, 0, 0, NewYork()),
+			BySeconds:         []int{0, 30},
+			AmbiguousBehavior: BothAmbiguous,
+		},
+		Dates:                  []string{"2007-11-04T01:59:00-04:00", "2007-11-04T01:59:30-04:00", "2007-11-04T01:00:00-05:00", "2007-11-04T01:00:30-05:00"},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
 	{
 		Name: "rfc: Monthly on the first Friday until December 24, 1997",
 		RRule: RRule{
@@ -475,6 +766,410 @@ var cases = []struct {
 			"1999-05-17T09:00:00-04:00",
 		},
 	},
+
+	{
+		Name: "rfc third to last day of month",
+		RRule: RRule{
+			Frequency:   Monthly,
+			Dtstart:     time.Date(1997, 9, 28, 9, 0, 0, 0, NewYork()),
+			Count:       6,
+			ByMonthDays: []int{-3},
+		},
+		String: "FREQ=MONTHLY;COUNT=6;BYMONTHDAY=-3",
+		Dates: []string{
+			"1997-09-28T09:00:00-04:00",
+			"1997-10-29T09:00:00-05:00",
+			"1997-11-28T09:00:00-05:00",
+			"1997-12-29T09:00:00-05:00",
+			"1998-01-29T09:00:00-05:00",
+			"1998-02-26T09:00:00-05:00",
+		},
+	},
+
+	{
+		Name: "rfc first and last day of month",
+		RRule: RRule{
+			Frequency:   Monthly,
+			Dtstart:     time.Date(1997, 9, 30, 9, 0, 0, 0, NewYork()),
+			Count:       10,
+			ByMonthDays: []int{1, -1},
+		},
+		String: "FREQ=MONTHLY;COUNT=10;BYMONTHDAY=1,-1",
+		Dates: []string{
+			"1997-09-30T09:00:00-04:00",
+			"1997-10-01T09:00:00-04:00",
+			"1997-10-31T09:00:00-05:00",
+			"1997-11-01T09:00:00-05:00",
+			"1997-11-30T09:00:00-05:00",
+			"1997-12-01T09:00:00-05:00",
+			"1997-12-31T09:00:00-05:00",
+			"1998-01-01T09:00:00-05:00",
+			"1998-01-31T09:00:00-05:00",
+			"1998-02-01T09:00:00-05:00",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "rfc every third year on yeardays",
+		RRule: RRule{
+			Frequency:  Yearly,
+			Dtstart:    time.Date(1997, 1, 1, 9, 0, 0, 0, NewYork()),
+			Count:      10,
+			Interval:   3,
+			ByYearDays: []int{1, 100, 200},
+		},
+		String: "FREQ=YEARLY;COUNT=10;INTERVAL=3;BYYEARDAY=1,100,200",
+		Dates: []string{
+			"1997-01-01T09:00:00-05:00",
+			"1997-04-10T09:00:00-04:00",
+			"1997-07-19T09:00:00-04:00",
+			"2000-01-01T09:00:00-05:00",
+			"2000-04-09T09:00:00-04:00",
+			"2000-07-18T09:00:00-04:00",
+			"2003-01-01T09:00:00-05:00",
+			"2003-04-10T09:00:00-04:00",
+			"2003-07-19T09:00:00-04:00",
+			"2006-01-01T09:00:00-05:00",
+		},
+		Terminal: true,
+	},
+
+	{
+		// the key is the first of each month, so that months without the
+		// 31st of the start are not skipped.
+		Name: "last day of month from the 31st",
+		RRule: RRule{
+			Frequency:   Monthly,
+			Dtstart:     time.Date(2019, 1, 31, 9, 0, 0, 0, time.UTC),
+			Count:       4,
+			ByMonthDays: []int{-1},
+		},
+		Dates: []string{
+			"2019-01-31T09:00:00Z",
+			"2019-02-28T09:00:00Z",
+			"2019-03-31T09:00:00Z",
+			"2019-04-30T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "last day of month on a friday",
+		RRule: RRule{
+			Frequency:   Monthly,
+			Dtstart:     time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:       3,
+			ByMonthDays: []int{-1},
+			ByWeekdays:  []QualifiedWeekday{{WD: time.Friday}},
+		},
+		Dates: []string{
+			"2019-05-31T09:00:00Z",
+			"2020-01-31T09:00:00Z",
+			"2020-07-31T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "negative monthday skip backward",
+		RRule: RRule{
+			Frequency:       Monthly,
+			Dtstart:         time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:           4,
+			ByMonthDays:     []int{-31},
+			InvalidBehavior: PrevInvalid,
+		},
+		String: "FREQ=MONTHLY;COUNT=4;BYMONTHDAY=-31;SKIP=BACKWARD;RSCALE=GREGORIAN",
+		Dates: []string{
+			"2019-01-01T09:00:00Z",
+			"2019-01-31T09:00:00Z",
+			"2019-03-01T09:00:00Z",
+			"2019-03-31T09:00:00Z",
+		},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "negative monthday skip forward",
+		RRule: RRule{
+			Frequency:       Monthly,
+			Dtstart:         time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:           4,
+			ByMonthDays:     []int{-31},
+			InvalidBehavior: NextInvalid,
+		},
+		Dates: []string{
+			"2019-01-01T09:00:00Z",
+			"2019-02-01T09:00:00Z",
+			"2019-03-01T09:00:00Z",
+			"2019-04-01T09:00:00Z",
+		},
+		Terminal:               true,
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "last day of february",
+		RRule: RRule{
+			Frequency:   Yearly,
+			Dtstart:     time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:       3,
+			ByMonths:    []time.Month{time.February},
+			ByMonthDays: []int{-1},
+		},
+		Dates: []string{
+			"2019-02-28T09:00:00Z",
+			"2020-02-29T09:00:00Z",
+			"2021-02-28T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "last days of the first quarter",
+		RRule: RRule{
+			Frequency:   Yearly,
+			Dtstart:     time.Date(1997, 9, 2, 9, 0, 0, 0, time.UTC),
+			Count:       5,
+			ByMonths:    []time.Month{time.January, time.February, time.March},
+			ByMonthDays: []int{-1},
+		},
+		Dates: []string{
+			"1998-01-31T09:00:00Z",
+			"1998-02-28T09:00:00Z",
+			"1998-03-31T09:00:00Z",
+			"1999-01-31T09:00:00Z",
+			"1999-02-28T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "negative yearday",
+		RRule: RRule{
+			Frequency:  Yearly,
+			Dtstart:    time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:      3,
+			ByYearDays: []int{-1},
+		},
+		String: "FREQ=YEARLY;COUNT=3;BYYEARDAY=-1",
+		Dates: []string{
+			"2019-12-31T09:00:00Z",
+			"2020-12-31T09:00:00Z",
+			"2021-12-31T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "negative yearday limited by month",
+		RRule: RRule{
+			Frequency:  Yearly,
+			Dtstart:    time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:      3,
+			ByYearDays: []int{1, -306},
+			ByMonths:   []time.Month{time.March},
+		},
+		Dates: []string{
+			"2019-03-01T09:00:00Z",
+			"2020-03-01T09:00:00Z",
+			"2021-03-01T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "negative yearday limited by weekday",
+		RRule: RRule{
+			Frequency:  Yearly,
+			Dtstart:    time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:      3,
+			ByYearDays: []int{-1, 1},
+			ByWeekdays: []QualifiedWeekday{{WD: time.Tuesday}},
+		},
+		Dates: []string{
+			"2019-01-01T09:00:00Z",
+			"2019-12-31T09:00:00Z",
+			"2024-12-31T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "daily negative monthdays",
+		RRule: RRule{
+			Frequency:   Daily,
+			Dtstart:     time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:       4,
+			ByMonthDays: []int{-1, -2},
+		},
+		Dates: []string{
+			"2019-01-30T09:00:00Z",
+			"2019-01-31T09:00:00Z",
+			"2019-02-27T09:00:00Z",
+			"2019-02-28T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "hourly negative yearday",
+		RRule: RRule{
+			Frequency:  Hourly,
+			Dtstart:    time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:      3,
+			ByYearDays: []int{-1},
+			ByHours:    []int{9, 10},
+		},
+		Dates: []string{
+			"2019-12-31T09:00:00Z",
+			"2019-12-31T10:00:00Z",
+			"2020-12-31T09:00:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "minutely negative monthday",
+		RRule: RRule{
+			Frequency:   Minutely,
+			Dtstart:     time.Date(2020, 2, 28, 23, 58, 0, 0, time.UTC),
+			Count:       3,
+			ByMonthDays: []int{-1},
+		},
+		Dates: []string{
+			"2020-02-29T00:00:00Z",
+			"2020-02-29T00:01:00Z",
+			"2020-02-29T00:02:00Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "secondly negative yearday",
+		RRule: RRule{
+			Frequency:  Secondly,
+			Dtstart:    time.Date(2019, 12, 31, 23, 59, 58, 0, time.UTC),
+			Count:      3,
+			ByYearDays: []int{-366},
+		},
+		Dates: []string{
+			"2020-01-01T00:00:00Z",
+			"2020-01-01T00:00:01Z",
+			"2020-01-01T00:00:02Z",
+		},
+		Terminal: true,
+	},
+
+	{
+		Name: "negative weekno",
+		RRule: RRule{
+			Frequency:     Yearly,
+			Dtstart:       time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:         3,
+			ByWeekNumbers: []int{-1},
+			ByWeekdays:    []QualifiedWeekday{{WD: time.Monday}},
+		},
+		String: "FREQ=YEARLY;COUNT=3;BYDAY=MO;BYWEEKNO=-1",
+		Dates: []string{
+			"2019-12-23T09:00:00Z",
+			"2020-12-28T09:00:00Z",
+			"2021-12-27T09:00:00Z",
+		},
+	},
+
+	{
+		// only years with 53 weeks have a week -53, which begins in the
+		// previous year.
+		Name: "weekno -53",
+		RRule: RRule{
+			Frequency:     Yearly,
+			Dtstart:       time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:         3,
+			ByWeekNumbers: []int{-53},
+			ByWeekdays:    []QualifiedWeekday{{WD: time.Thursday}},
+		},
+		Dates: []string{
+			"2020-01-02T09:00:00Z",
+			"2026-01-01T09:00:00Z",
+			"2032-01-01T09:00:00Z",
+		},
+	},
+
+	{
+		Name: "weekno with sunday week start",
+		RRule: RRule{
+			Frequency:     Yearly,
+			Dtstart:       time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:         4,
+			ByWeekNumbers: []int{1},
+			ByWeekdays:    []QualifiedWeekday{{WD: time.Sunday}},
+			WeekStart:     weekday(time.Sunday),
+		},
+		String: "FREQ=YEARLY;COUNT=4;BYDAY=SU;BYWEEKNO=1;WKST=SU",
+		Dates: []string{
+			"2018-12-30T09:00:00Z",
+			"2019-12-29T09:00:00Z",
+			"2021-01-03T09:00:00Z",
+			"2022-01-02T09:00:00Z",
+		},
+	},
+
+	{
+		Name: "weekno 53 skip forward",
+		RRule: RRule{
+			Frequency:       Yearly,
+			Dtstart:         time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:           3,
+			ByWeekNumbers:   []int{53},
+			ByWeekdays:      []QualifiedWeekday{{WD: time.Monday}},
+			InvalidBehavior: NextInvalid,
+		},
+		Dates: []string{
+			"2019-12-30T09:00:00Z",
+			"2020-12-28T09:00:00Z",
+			"2022-01-03T09:00:00Z",
+		},
+		NoTeambitionComparison: true,
+	},
+
+	{
+		Name: "weekno 53 skip backward",
+		RRule: RRule{
+			Frequency:       Yearly,
+			Dtstart:         time.Date(2019, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:           3,
+			ByWeekNumbers:   []int{53},
+			ByWeekdays:      []QualifiedWeekday{{WD: time.Monday}},
+			InvalidBehavior: PrevInvalid,
+		},
+		Dates: []string{
+			"2019-12-23T09:00:00Z",
+			"2020-12-28T09:00:00Z",
+			"2021-12-27T09:00:00Z",
+		},
+		NoTeambitionComparison: true,
+	},
+
+	{
+		// January 1st of 2016 and 2021 are in the 53rd week of the previous
+		// year.
+		Name: "weekno limiting bymonth",
+		RRule: RRule{
+			Frequency:     Yearly,
+			Dtstart:       time.Date(2015, 1, 1, 9, 0, 0, 0, time.UTC),
+			Count:         2,
+			ByWeekNumbers: []int{53},
+			ByMonths:      []time.Month{time.January},
+			ByWeekdays:    []QualifiedWeekday{{WD: time.Friday}},
+		},
+		Dates: []string{
+			"2016-01-01T09:00:00Z",
+			"2021-01-01T09:00:00Z",
+		},
+	},
 }
 
 func MustRRule(str string) RRule {
@@ -485,6 +1180,10 @@ func MustRRule(str string) RRule {
 	return r
 }
 
+func weekday(wd time.Weekday) *time.Weekday {
+	return &wd
+}
+
 func NewYork() *time.Location {
 	return mustLoadLoc("America/New_York")
 }
@@ -566,6 +1265,7 @@ func BenchmarkRRule(b *testing.B) {
 		}
 
 		b.Run(tc.Name, func(b *testing.B) {
+			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
 				All(tc.RRule.Iterator(), 0)
 			}
@@ -573,6 +1273,23 @@ func BenchmarkRRule(b *testing.B) {
 	}
 }
 
+func BenchmarkRRuleTimes(b *testing.B) {
+	for _, tc := range cases {
+		if tc.NoBenchmark {
+			continue
+		}
+
+		b.Run(tc.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				it, _ := tc.RRule.NewTimeIterator()
+				for _, ok := it.Next(); ok; _, ok = it.Next() {
+				}
+			}
+		})
+	}
+}
+
 func rruleToROption(r RRule) rrule.ROption {
 	if r.InvalidBehavior != OmitInvalid {
 		panic("cannot convert non-omit SKIP values to teambition")
@@ -597,6 +1314,10 @@ func rruleToROption(r RRule) rrule.ROption {
 		Byweekday: make([]rrule.Weekday, 0, len(r.ByWeekdays)),
 	}
 
+	if r.WeekStart != nil {
+		converted.Wkst = teambitionWeekday(*r.WeekStart, 0)
+	}
+
 	switch r.Frequency {
 	case Secondly:
 		converted.Freq = rrule.SECONDLY
@@ -618,27 +1339,30 @@ func rruleToROption(r RRule) rrule.ROption {
 		converted.Bymonth = append(converted.Bymonth, int(m))
 	}
 	for _, wd := range r.ByWeekdays {
-		switch wd.WD {
-		case time.Sunday:
-			converted.Byweekday = append(converted.Byweekday, rrule.SU.Nth(wd.N))
-		case time.Monday:
-			converted.Byweekday = append(converted.Byweekday, rrule.MO.Nth(wd.N))
-		case time.Tuesday:
-			converted.Byweekday = append(converted.Byweekday, rrule.TU.Nth(wd.N))
-		case time.Wednesday:
-			converted.Byweekday = append(converted.Byweekday, rrule.WE.Nth(wd.N))
-		case time.Thursday:
-			converted.Byweekday = append(converted.Byweekday, rrule.TH.Nth(wd.N))
-		case time.Friday:
-			converted.Byweekday = append(converted.Byweekday, rrule.FR.Nth(wd.N))
-		case time.Saturday:
-			converted.Byweekday = append(converted.Byweekday, rrule.SA.Nth(wd.N))
-		}
+		converted.Byweekday = append(converted.Byweekday, teambitionWeekday(wd.WD, wd.N))
 	}
 
 	return converted
 }
 
+func teambitionWeekday(wd time.Weekday, n int) rrule.Weekday {
+	switch wd {
+	case time.Sunday:
+		return rrule.SU.Nth(n)
+	case time.Monday:
+		return rrule.MO.Nth(n)
+	case time.Tuesday:
+		return rrule.TU.Nth(n)
+	case time.Wednesday:
+		return rrule.WE.Nth(n)
+	case time.Thursday:
+		return rrule.TH.Nth(n)
+	case time.Friday:
+		return rrule.FR.Nth(n)
+	}
+	return rrule.SA.Nth(n)
+}
+
 func BenchmarkTeambition(b *testing.B) {
 	for _, tc := range cases {
 
@@ -651,6 +1375,7 @@ func BenchmarkTeambition(b *testing.B) {
 		}
 
 		b.Run(tc.Name, func(b *testing.B) {
+			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
 				teambitionRRule, _ := rrule.NewRRule(ro)
 				teambitionRRule.All()
@@ -667,3 +1392,136 @@ func rfcAll(times []time.Time) []string {
 	}
 	return strs
 }
+
+func TestNewIteratorInvalid(t *testing.T) {
+	_, err := RRule{Frequency: Daily, Count: 3, Until: now}.NewIterator()
+	assert.Error(t, err)
+
+	_, err = RRule{Frequency: Frequency(-1)}.NewIterator()
+	assert.IsType(t, &ValidationError{}, err)
+	var freqErr *FrequencyError
+	require.True(t, errors.As(err, &freqErr))
+	assert.Equal(t, Frequency(-1), freqErr.Frequency)
+
+	_, err = Recurrence{
+		Dtstart: now,
+		RRules:  []RRule{{Frequency: Daily}},
+		ExRules: []RRule{{Frequency: Frequency(9)}},
+	}.NewIterator()
+	assert.Error(t, err)
+
+	assert.Panics(t, func() { RRule{Frequency: Frequency(9)}.Iterator() })
+	assert.Panics(t, func() { _ = Frequency(9).String() })
+}
+
+func TestValidate(t *testing.T) {
+	wkst := time.Weekday(8)
+
+	cases := []struct {
+		Name  string
+		RRule RRule
+		Parts []string
+	}{
+		{
+			Name:  "valid",
+			RRule: MustRRule("FREQ=MONTHLY;BYDAY=1MO,-1FR;BYSETPOS=1"),
+		},
+		{
+			Name:  "count and until",
+			RRule: RRule{Frequency: Daily, Count: 2, Until: now},
+			Parts: []string{"COUNT/UNTIL"},
+		},
+		{
+			Name:  "every problem",
+			RRule: RRule{Frequency: Weekly, Interval: -2, ByWeekdays: []QualifiedWeekday{{N: 2, WD: time.Monday}}, ByMonthDays: []int{3}, ByMonths: []time.Month{0, 13}, WeekStart: &wkst},
+			Parts: []string{"INTERVAL", "BYDAY", "BYMONTHDAY", "BYMONTH", "BYMONTH", "WKST"},
+		},
+		{
+			Name:  "byyearday with monthly",
+			RRule: RRule{Frequency: Monthly, ByYearDays: []int{0, 100}},
+			Parts: []string{"BYYEARDAY", "BYYEARDAY"},
+		},
+		{
+			Name:  "byweekno out of range",
+			RRule: RRule{Frequency: Yearly, ByWeekNumbers: []int{54}},
+			Parts: []string{"BYWEEKNO"},
+		},
+		{
+			Name:  "byweekno with daily",
+			RRule: RRule{Frequency: Daily, ByWeekNumbers: []int{54}},
+			Parts: []string{"BYWEEKNO", "BYWEEKNO"},
+		},
+		{
+			Name:  "bysetpos alone",
+			RRule: RRule{Frequency: Yearly, BySetPos: []int{0}},
+			Parts: []string{"BYSETPOS", "BYSETPOS"},
+		},
+		{
+			Name:  "byday numeric with byweekno",
+			RRule: RRule{Frequency: Yearly, ByWeekNumbers: []int{1}, ByWeekdays: []QualifiedWeekday{{N: 1, WD: time.Monday}}},
+			Parts: []string{"BYDAY"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.RRule.Validate()
+			if len(tc.Parts) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.IsType(t, &ValidationError{}, err)
+			var parts []string
+			for _, p := range err.(*ValidationError).Problems {
+				parts = append(parts, p.Part)
+				assert.Equal(t, "3.3.10", p.Section)
+				assert.NotEmpty(t, p.Value)
+			}
+			assert.Equal(t, tc.Parts, parts)
+		})
+	}
+}
+
+func TestParseRRuleInterval(t *testing.T) {
+	_, err := ParseRRule("FREQ=DAILY;INTERVAL=0")
+	require.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, "INTERVAL=0: INTERVAL must be a positive integer (RFC 5545 section 3.3.10)", err.Error())
+}
+
+func TestUntilDate(t *testing.T) {
+	rrule, err := ParseRRule("FREQ=DAILY;UNTIL=20240105")
+	require.NoError(t, err)
+	assert.True(t, rrule.UntilDate)
+	assert.Equal(t, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), rrule.Until)
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20240105", rrule.String())
+
+	// the whole date of UNTIL is included, in the location of Dtstart.
+	rrule.Dtstart = time.Date(2024, 1, 1, 21, 0, 0, 0, NewYork())
+	assert.Equal(t, []string{
+		"2024-01-01T21:00:00-05:00",
+		"2024-01-02T21:00:00-05:00",
+		"2024-01-03T21:00:00-05:00",
+		"2024-01-04T21:00:00-05:00",
+		"2024-01-05T21:00:00-05:00",
+	}, rfcAll(All(rrule.Iterator(), 0)))
+
+	last, ok := rrule.Last()
+	assert.True(t, ok)
+	assert.Equal(t, "2024-01-05T21:00:00-05:00", last.Format(time.RFC3339))
+
+	// as a time, UNTIL is midnight UTC, which is 7pm the day before in New York.
+	rrule.UntilDate = false
+	assert.Len(t, All(rrule.Iterator(), 0), 3)
+}
+
+func TestYearlyByMonthOutsideDtstartMonth(t *testing.T) {
+	rrule := MustRRule("FREQ=YEARLY;BYMONTH=11;BYDAY=1SU;COUNT=3")
+	rrule.Dtstart = time.Date(2001, time.January, 1, 2, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []string{
+		"2001-11-04T02:00:00Z",
+		"2002-11-03T02:00:00Z",
+		"2003-11-02T02:00:00Z",
+	}, rfcAll(All(rrule.Iterator(), 0)))
+}
@@ -0,0 +1,181 @@
+package rrule
+
+import (
+	"iter"
+	"time"
+)
+
+// All returns a sequence of the occurrences of the pattern, for use with
+// range. Unless the pattern is bounded by UNTIL or COUNT, the sequence never
+// ends on its own, so the loop must break. An invalid pattern yields nothing.
+func (rrule RRule) All() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		it, err := rrule.iterator()
+		if err != nil {
+			return
+		}
+		seq(it, yield)
+	}
+}
+
+// AllIndexed is like All, but also yields the zero-based position of each
+// occurrence.
+func (rrule RRule) AllIndexed() iter.Seq2[int, time.Time] {
+	return func(yield func(int, time.Time) bool) {
+		it, err := rrule.iterator()
+		if err != nil {
+			return
+		}
+		seqIndexed(it, 0, yield)
+	}
+}
+
+// Window returns a sequence of the occurrences of the pattern after a and
+// before b, like Between. If inc is true, occurrences equal to a or b are
+// included.
+func (rrule RRule) Window(a, b time.Time, inc bool) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		it, err := rrule.iterator()
+		if err != nil {
+			return
+		}
+		it.SkipTo(a)
+		seq(windowed(it, a, b, inc), yield)
+	}
+}
+
+// WindowIndexed is like Window, but also yields the zero-based position of each
+// occurrence among all the occurrences of the pattern.
+//
+// Positions are counted arithmetically for patterns with no BYxxx rule parts.
+// Other patterns are scanned from Dtstart to count the occurrences before a.
+func (rrule RRule) WindowIndexed(a, b time.Time, inc bool) iter.Seq2[int, time.Time] {
+	return func(yield func(int, time.Time) bool) {
+		it, err := rrule.iterator()
+		if err != nil {
+			return
+		}
+
+		it.SkipTo(a)
+		if _, ok := it.Ordinal(); !ok {
+			it, _ = rrule.iterator()
+			skipByScanning(it, a)
+		}
+
+		w := windowed(it, a, b, inc)
+		w.peek() // pass over a, if it is excluded, before counting
+		ord, _ := it.Ordinal()
+		seqIndexed(w, ord, yield)
+	}
+}
+
+// All returns a sequence of the occurrences of the recurrence, for use with
+// range. An invalid recurrence yields nothing.
+func (r Recurrence) All() iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		it, err := r.iterator()
+		if err != nil {
+			return
+		}
+		seq(it, yield)
+	}
+}
+
+// AllIndexed is like All, but also yields the zero-based position of each
+// occurrence.
+func (r Recurrence) AllIndexed() iter.Seq2[int, time.Time] {
+	return func(yield func(int, time.Time) bool) {
+		it, err := r.iterator()
+		if err != nil {
+			return
+		}
+		seqIndexed(it, 0, yield)
+	}
+}
+
+// Window returns a sequence of the occurrences of the recurrence after a and
+// before b, like Between. If inc is true, occurrences equal to a or b are
+// included.
+func (r Recurrence) Window(a, b time.Time, inc bool) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		it, err := r.iterator()
+		if err != nil {
+			return
+		}
+		it.SkipTo(a)
+		seq(windowed(it, a, b, inc), yield)
+	}
+}
+
+// WindowIndexed is like Window, but also yields the zero-based position of each
+// occurrence among all the occurrences of the recurrence. The recurrence is
+// scanned from its first occurrence to count the occurrences before a.
+func (r Recurrence) WindowIndexed(a, b time.Time, inc bool) iter.Seq2[int, time.Time] {
+	return func(yield func(int, time.Time) bool) {
+		it, err := r.iterator()
+		if err != nil {
+			return
+		}
+		skipByScanning(it, a)
+		w := windowed(it, a, b, inc)
+		w.peek() // pass over a, if it is excluded, before counting
+		seqIndexed(w, it.ordinal, yield)
+	}
+}
+
+// seq yields the times of it until it ends or yield returns false.
+func seq(it timeSource, yield func(time.Time) bool) {
+	for next, ok := it.next(); ok; next, ok = it.next() {
+		if !yield(next) {
+			return
+		}
+	}
+}
+
+// seqIndexed yields the times of it numbered from first.
+func seqIndexed(it timeSource, first int, yield func(int, time.Time) bool) {
+	n := first
+	for next, ok := it.next(); ok; next, ok = it.next() {
+		if !yield(n, next) {
+			return
+		}
+		n++
+	}
+}
+
+// window ends an ascending iterator at the end of a window, and passes over
+// times at its beginning that are excluded.
+type window struct {
+	it   timeSource
+	a, b time.Time
+	inc  bool
+}
+
+func windowed(it timeSource, a, b time.Time, inc bool) *window {
+	return &window{it: it, a: a, b: b, inc: inc}
+}
+
+func (w *window) peek() (time.Time, bool) {
+	for {
+		next, ok := w.it.peek()
+		if !ok {
+			return next, false
+		}
+		if next.Before(w.a) || (!w.inc && next.Equal(w.a)) {
+			w.it.next()
+			continue
+		}
+		if next.After(w.b) || (!w.inc && next.Equal(w.b)) {
+			return time.Time{}, false
+		}
+		return next, true
+	}
+}
+
+func (w *window) next() (time.Time, bool) {
+	next, ok := w.peek()
+	if ok {
+		w.it.next()
+	}
+	return next, ok
+}
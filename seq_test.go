@@ -0,0 +1,123 @@
+package rrule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllSeq(t *testing.T) {
+	for _, tc := range cases {
+		if tc.NoTest {
+			continue
+		}
+
+		t.Run(tc.Name, func(t *testing.T) {
+			expected := All(tc.RRule.Iterator(), 40)
+
+			var got []time.Time
+			for d := range tc.RRule.All() {
+				got = append(got, d)
+				if len(got) == 40 {
+					break
+				}
+			}
+			assert.Equal(t, expected, got)
+
+			n := 0
+			for idx, d := range tc.RRule.AllIndexed() {
+				assert.Equal(t, n, idx)
+				assert.Equal(t, expected[idx], d)
+				n++
+				if n == len(expected) {
+					break
+				}
+			}
+		})
+	}
+
+	for range (RRule{Frequency: Frequency(12)}).All() {
+		t.Fatal("invalid pattern yielded a time")
+	}
+}
+
+func TestWindowSeq(t *testing.T) {
+	for _, str := range windowRRules {
+		t.Run(str, func(t *testing.T) {
+			rrule := MustRRule(str)
+			rrule.Dtstart = now
+
+			all := All(rrule.Iterator(), 150)
+			require.Len(t, all, 150)
+
+			for _, window := range [][2]int{{0, 10}, {17, 18}, {40, 149}, {99, 99}} {
+				a, b := all[window[0]], all[window[1]]
+
+				t.Run(fmt.Sprintf("%d-%d", window[0], window[1]), func(t *testing.T) {
+					for _, inc := range []bool{true, false} {
+						var got []time.Time
+						for d := range rrule.Window(a, b, inc) {
+							got = append(got, d)
+						}
+						assert.Equal(t, rrule.Between(a, b, inc), got, inc)
+
+						first := window[0]
+						if !inc {
+							first++
+						}
+						for idx, d := range rrule.WindowIndexed(a, b, inc) {
+							assert.Equal(t, first, idx, inc)
+							assert.Equal(t, all[idx], d, inc)
+							first++
+						}
+					}
+				})
+			}
+
+			// stop early
+			n := 0
+			for range rrule.Window(all[0], all[149], true) {
+				n++
+				if n == 3 {
+					break
+				}
+			}
+			assert.Equal(t, 3, n)
+		})
+	}
+}
+
+func TestRecurrenceSeq(t *testing.T) {
+	for _, tc := range recurrenceCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			all := All(tc.Recurrence.Iterator(), 30)
+
+			var got []time.Time
+			for idx, d := range tc.Recurrence.AllIndexed() {
+				assert.Equal(t, len(got), idx)
+				got = append(got, d)
+				if len(got) == len(all) {
+					break
+				}
+			}
+			assert.Equal(t, all, got)
+
+			if len(all) < 3 {
+				return
+			}
+
+			got = nil
+			for d := range tc.Recurrence.Window(all[1], all[len(all)-1], false) {
+				got = append(got, d)
+			}
+			assert.Equal(t, tc.Recurrence.Between(all[1], all[len(all)-1], false), got)
+
+			for idx, d := range tc.Recurrence.WindowIndexed(all[1], all[len(all)-1], true) {
+				assert.Equal(t, all[idx], d)
+			}
+		})
+	}
+}
@@ -14,7 +14,9 @@ func (rrule RRule) String() string {
 
 	if !rrule.Until.IsZero() {
 		str.WriteString(";UNTIL=")
-		if rrule.UntilFloating {
+		if rrule.UntilDate {
+			str.WriteString(rrule.Until.Format(rfc5545Date))
+		} else if rrule.UntilFloating {
 			str.WriteString(rrule.Until.Format(rfc5545WithoutOffset))
 		} else {
 			str.WriteString(rrule.Until.Format(rfc5545WithOffset))
@@ -0,0 +1,133 @@
+package rrule
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// MarshalText returns the RFC 5545 form of the RRule, as String does.
+func (rrule RRule) MarshalText() ([]byte, error) {
+	if _, err := freqToStr(rrule.Frequency); err != nil {
+		return nil, err
+	}
+	return []byte(rrule.String()), nil
+}
+
+// UnmarshalText parses an RRule as ParseRRule does. Empty text is the zero
+// RRule.
+func (rrule *RRule) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*rrule = RRule{}
+		return nil
+	}
+
+	parsed, err := ParseRRule(string(text))
+	if err != nil {
+		return err
+	}
+	*rrule = parsed
+	return nil
+}
+
+// Value returns the RFC 5545 form of the RRule as a string, which is empty for
+// the zero RRule, as Scan reads it. It is never NULL; use a pointer or sql.Null
+// for a nullable column.
+func (rrule RRule) Value() (driver.Value, error) {
+	if rrule.isZero() {
+		return "", nil
+	}
+
+	text, err := rrule.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// isZero reports whether every field of the RRule is unset.
+func (rrule RRule) isZero() bool {
+	return rrule.Frequency == 0 &&
+		rrule.Until.IsZero() &&
+		!rrule.UntilFloating &&
+		!rrule.UntilDate &&
+		rrule.Count == 0 &&
+		rrule.Dtstart.IsZero() &&
+		rrule.Interval == 0 &&
+		len(rrule.BySeconds) == 0 &&
+		len(rrule.ByMinutes) == 0 &&
+		len(rrule.ByHours) == 0 &&
+		len(rrule.ByWeekdays) == 0 &&
+		len(rrule.ByMonthDays) == 0 &&
+		len(rrule.ByWeekNumbers) == 0 &&
+		len(rrule.ByMonths) == 0 &&
+		len(rrule.ByYearDays) == 0 &&
+		len(rrule.BySetPos) == 0 &&
+		rrule.InvalidBehavior == 0 &&
+		rrule.NonexistentBehavior == 0 &&
+		rrule.AmbiguousBehavior == 0 &&
+		rrule.WeekStart == nil
+}
+
+// Scan parses a string or []byte as UnmarshalText does. NULL is the zero
+// RRule, like empty text.
+func (rrule *RRule) Scan(src any) error {
+	text, err := scanText(src, "RRule")
+	if err != nil {
+		return err
+	}
+	return rrule.UnmarshalText(text)
+}
+
+// MarshalText returns the RFC 5545 form of the recurrence, as String does. The
+// zero Recurrence is empty text.
+func (r Recurrence) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses a recurrence as ParseRecurrence does with a nil
+// location, so floating times are read in UTC. FloatingLocation is set for
+// them, so that MarshalText writes them as floating times again. Use
+// ParseRecurrence to read floating times in another location. Empty text is the
+// zero Recurrence.
+func (r *Recurrence) UnmarshalText(text []byte) error {
+	parsed, err := ParseRecurrence(text, nil)
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// Value returns the RFC 5545 form of the recurrence as a string, which is empty
+// for the zero Recurrence. It is never NULL; use a pointer or sql.Null for a
+// nullable column.
+func (r Recurrence) Value() (driver.Value, error) {
+	text, err := r.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(text), nil
+}
+
+// Scan parses a string or []byte as UnmarshalText does. NULL is the zero
+// Recurrence, like empty text.
+func (r *Recurrence) Scan(src any) error {
+	text, err := scanText(src, "Recurrence")
+	if err != nil {
+		return err
+	}
+	return r.UnmarshalText(text)
+}
+
+// scanText returns the text of a database value, which is empty for NULL.
+func scanText(src any, name string) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(src), nil
+	case []byte:
+		return src, nil
+	}
+	return nil, fmt.Errorf("cannot scan %T into %s", src, name)
+}
@@ -0,0 +1,126 @@
+package rrule
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRRuleText(t *testing.T) {
+	rrule, err := ParseRRule("FREQ=WEEKLY;UNTIL=20240131T090000Z;BYDAY=MO,WE")
+	require.NoError(t, err)
+
+	text, err := rrule.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;UNTIL=20240131T090000Z;BYDAY=MO,WE", string(text))
+
+	var decoded RRule
+	require.NoError(t, decoded.UnmarshalText(text))
+	assert.Equal(t, rrule, decoded)
+
+	require.NoError(t, decoded.UnmarshalText(nil))
+	assert.Equal(t, RRule{}, decoded)
+
+	assert.Error(t, decoded.UnmarshalText([]byte("FREQ=FORTNIGHTLY")))
+	_, err = RRule{Frequency: 99}.MarshalText()
+	assert.Error(t, err)
+}
+
+func TestRecurrenceText(t *testing.T) {
+	src := "DTSTART:20240101T090000\n" +
+		"RRULE:FREQ=DAILY;COUNT=3\n" +
+		"EXDATE:20240102T090000\n"
+
+	var r Recurrence
+	require.NoError(t, r.UnmarshalText([]byte(src)))
+	assert.True(t, r.FloatingLocation)
+	assert.Equal(t, time.UTC, r.Dtstart.Location())
+
+	text, err := r.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, src, string(text))
+
+	require.NoError(t, r.UnmarshalText(nil))
+	assert.Equal(t, Recurrence{}, r)
+	text, err = r.MarshalText()
+	require.NoError(t, err)
+	assert.Empty(t, text)
+
+	assert.Error(t, r.UnmarshalText([]byte("DTSTART:bad\n")))
+}
+
+func TestSQL(t *testing.T) {
+	src := "DTSTART;TZID=America/New_York:20240101T090000\nRRULE:FREQ=WEEKLY;COUNT=2\n"
+
+	var r Recurrence
+	require.NoError(t, r.Scan([]byte(src)))
+	assert.Equal(t, "America/New_York", r.Dtstart.Location().String())
+	v, err := r.Value()
+	require.NoError(t, err)
+	assert.Equal(t, src, v)
+
+	require.NoError(t, r.Scan(nil))
+	assert.Equal(t, Recurrence{}, r)
+	v, err = r.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+
+	var rrule RRule
+	require.NoError(t, rrule.Scan("FREQ=DAILY;INTERVAL=2"))
+	assert.Equal(t, RRule{Frequency: Daily, Interval: 2}, rrule)
+	v, err = rrule.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=DAILY;INTERVAL=2", v)
+
+	require.NoError(t, rrule.Scan(""))
+	assert.Equal(t, RRule{}, rrule)
+	v, err = rrule.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "", v)
+	v, err = RRule{Frequency: Secondly, Count: 1}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=SECONDLY;COUNT=1", v)
+	v, err = RRule{Frequency: Secondly, BySeconds: []int{}, WeekStart: weekday(time.Monday)}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=SECONDLY;WKST=MO", v)
+
+	assert.Error(t, rrule.Scan(42))
+	assert.Error(t, r.Scan(time.Now()))
+	assert.Error(t, rrule.Scan("FREQ=FORTNIGHTLY"))
+
+	// nullable columns use sql.Null, which leaves NULL distinct from empty.
+	var nullable sql.Null[RRule]
+	require.NoError(t, nullable.Scan(nil))
+	assert.False(t, nullable.Valid)
+	require.NoError(t, nullable.Scan("FREQ=DAILY"))
+	assert.True(t, nullable.Valid)
+	assert.Equal(t, Daily, nullable.V.Frequency)
+}
+
+func TestJSONText(t *testing.T) {
+	var doc struct {
+		Rule       RRule
+		Recurrence Recurrence
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"Rule": "FREQ=MONTHLY;BYMONTHDAY=-1",
+		"Recurrence": "DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;COUNT=2\n"
+	}`), &doc))
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=-1", doc.Rule.String())
+	assert.Equal(t, []string{
+		"2024-01-01T09:00:00Z",
+		"2024-01-02T09:00:00Z",
+	}, rfcAll(All(doc.Recurrence.Iterator(), 0)))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"Rule": null, "Recurrence": ""}`), &doc))
+	assert.Equal(t, RRule{}, doc.Rule)
+	assert.Equal(t, Recurrence{}, doc.Recurrence)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"Rule": "FREQ=FORTNIGHTLY"}`), &doc))
+	assert.Error(t, json.Unmarshal([]byte(`{"Rule": "\x"}`), &doc))
+}
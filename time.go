@@ -1,7 +1,6 @@
 package rrule
 
 import (
-	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -11,13 +10,39 @@ import (
 const (
 	rfc5545WithOffset    = "20060102T150405Z0700"
 	rfc5545WithoutOffset = "20060102T150405"
+	rfc5545Date          = "20060102"
 )
 
 // parseTime parses the time. the boolean is true if the time was in "local" (aka "floating")
 // time, and thus the defautlLoc was used.
+//
+// str may be a whole content line, such as DTSTART;TZID=America/New_York:19970902T090000,
+// an RRULE part, such as UNTIL=19970902T090000Z, or just the time.
 func parseTime(str string, defaultLoc *time.Location) (time.Time, bool, error) {
-	//        DTSTART;TZID=America/New_York:19970902T090000
+	if line, err := parseContentLine(str); err == nil {
+		return parseTimeLine(line, nil, defaultLoc)
+	}
+
+	if eqIdx := strings.IndexByte(str, '='); eqIdx >= 0 {
+		str = str[eqIdx+1:]
+	}
+
+	return parseTimeValue(str, nil, defaultLoc)
+}
+
+// parseTimeLine parses the time that is the value of a content line, in the
+// location named by its TZID parameter, if it has one.
+func parseTimeLine(line contentLine, z zones, defaultLoc *time.Location) (time.Time, bool, error) {
+	zone, err := z.lineZone(line)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return parseTimeValue(line.value, zone, defaultLoc)
+}
 
+// parseTimeValue parses a DATE-TIME value. If zone is not nil, the time is in
+// that location, and otherwise, if it has no offset, it is in defaultLoc.
+func parseTimeValue(str string, zone *time.Location, defaultLoc *time.Location) (time.Time, bool, error) {
 	var t time.Time
 
 	if defaultLoc == nil {
@@ -26,24 +51,9 @@ func parseTime(str string, defaultLoc *time.Location) (time.Time, bool, error) {
 	loc := defaultLoc
 	tzidFound := false
 
-	if idBeg := strings.Index(str, ";TZID="); idBeg >= 0 {
-		locBeg := idBeg + 6
-		locEnd := locBeg + strings.Index(str[locBeg:], ":")
-		if locEnd < 0 {
-			return t, false, errors.New("no end to TZID")
-		}
-
-		var err error
-		loc, err = LoadLocation(str[locBeg:locEnd])
-		if err != nil {
-			return t, false, err
-		}
-
+	if zone != nil {
+		loc = zone
 		tzidFound = true
-		str = str[locEnd+1:]
-	} else {
-		colonIdx := strings.IndexAny(str, ":=")
-		str = str[colonIdx+1:]
 	}
 
 	offsetFound := true
@@ -77,23 +87,40 @@ func parseTime(str string, defaultLoc *time.Location) (time.Time, bool, error) {
 	// in the 2am range, but the parsed time is less than 2 o'clock, advance an hour.
 	if tMinusHour := t.Add(-1 * time.Hour); t.Hour() == tMinusHour.Hour() {
 		t = tMinusHour
-	} else if twoAMRegex.MatchString(str) {
+	} else if t.Hour() < 2 && twoAMRegex.MatchString(str) {
 		t = t.Add(1 * time.Hour)
 	}
 
 	return t, !(tzidFound || offsetFound), err
 }
 
+// parseDateValue parses a DATE value as midnight in defaultLoc, or in UTC if
+// defaultLoc is nil.
+func parseDateValue(str string, defaultLoc *time.Location) (time.Time, error) {
+	if defaultLoc == nil {
+		defaultLoc = time.UTC
+	}
+	return time.ParseInLocation(rfc5545Date, str, defaultLoc)
+}
+
 var twoAMRegex = regexp.MustCompile("T02[0-9]{4}(Z|[0-9]{4})?$")
 
 func formatTime(prefix string, t time.Time, floatingLocation bool) string {
+	params, value := formatTimeParts(t, floatingLocation)
+	return prefix + params + ":" + value
+}
+
+// formatTimeParts returns the parameters and value with which t is written as
+// a DATE-TIME. params is either empty or a TZID parameter, including its
+// leading semicolon.
+func formatTimeParts(t time.Time, floatingLocation bool) (params, value string) {
 	if floatingLocation {
-		return fmt.Sprintf("%s:%s", prefix, t.Format(rfc5545WithoutOffset))
+		return "", t.Format(rfc5545WithoutOffset)
 	}
 
 	if t.Location() == time.UTC {
-		return fmt.Sprintf("%s:%sZ", prefix, t.Format(rfc5545WithoutOffset))
+		return "", t.Format(rfc5545WithoutOffset) + "Z"
 	}
 
-	return fmt.Sprintf("%s;TZID=%s:%s", prefix, t.Location(), t.Format(rfc5545WithoutOffset))
+	return fmt.Sprintf(";TZID=%s", t.Location()), t.Format(rfc5545WithoutOffset)
 }
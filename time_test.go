@@ -54,6 +54,16 @@ func TestParseTime(t *testing.T) {
 			Expected:         time.Date(2007, time.November, 4, 1, 30, 0, 0, NewYork()),
 			ExpectedFloating: false,
 		},
+		{
+			Input:            "DTSTART:20070311T023000",
+			Expected:         time.Date(2007, time.March, 11, 2, 30, 0, 0, time.UTC),
+			ExpectedFloating: true,
+		},
+		{
+			Input:            "DTSTART;TZID=America/New_York:20070312T023000",
+			Expected:         time.Date(2007, time.March, 12, 2, 30, 0, 0, NewYork()),
+			ExpectedFloating: false,
+		},
 		{
 			Input:            "DTSTART;TZID=America/New_York:20070311T023000",
 			Expected:         time.Date(2007, time.March, 11, 3, 30, 0, 0, NewYork()),
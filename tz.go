@@ -6,3 +6,26 @@ import "time"
 // but that implementation does not work on every platform. Set this
 // to an alternative implementation when necessary.
 var LoadLocation = time.LoadLocation
+
+// zones holds the locations defined by the VTIMEZONE components of an
+// iCalendar object, by TZID.
+type zones map[string]*time.Location
+
+// load returns the location named by tzid, preferring the definitions in z to
+// LoadLocation.
+func (z zones) load(tzid string) (*time.Location, error) {
+	if loc, ok := z[tzid]; ok {
+		return loc, nil
+	}
+	return LoadLocation(tzid)
+}
+
+// lineZone returns the location named by the TZID parameter of line, or nil if
+// it has none.
+func (z zones) lineZone(line contentLine) (*time.Location, error) {
+	tzid, ok := line.param("TZID")
+	if !ok || tzid == "" {
+		return nil, nil
+	}
+	return z.load(tzid)
+}
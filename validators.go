@@ -71,6 +71,8 @@ func validWeekday(weekdays []QualifiedWeekday) validFunc {
 	}
 }
 
+// validMonthDay checks the day of the month. Negative values count back from
+// the last day of the month.
 func validMonthDay(monthdays []int) validFunc {
 	if len(monthdays) == 0 {
 		return alwaysValid
@@ -82,11 +84,13 @@ func validMonthDay(monthdays []int) validFunc {
 		if t == nil {
 			return false
 		}
-		return m[t.Day()]
+		return m[t.Day()] || m[t.Day()-lastOfMonth(*t).Day()-1]
 	}
 }
 
-func validWeek(weeks []int) validFunc {
+// validWeek checks the week of the year, for weeks starting on wkstart, as
+// numbered by weekNumber. Negative values count back from the last week.
+func validWeek(weeks []int, wkstart time.Weekday) validFunc {
 	if len(weeks) == 0 {
 		return alwaysValid
 	}
@@ -97,7 +101,8 @@ func validWeek(weeks []int) validFunc {
 		if t == nil {
 			return false
 		}
-		return m[1+t.YearDay()/7]
+		_, week, n := weekNumber(*t, wkstart)
+		return m[week] || m[week-n-1]
 	}
 }
 
@@ -116,6 +121,8 @@ func validMonth(months []time.Month) validFunc {
 	}
 }
 
+// validYearDay checks the day of the year. Negative values count back from the
+// last day of the year.
 func validYearDay(yeardays []int) validFunc {
 	if len(yeardays) == 0 {
 		return alwaysValid
@@ -127,6 +134,6 @@ func validYearDay(yeardays []int) validFunc {
 		if t == nil {
 			return false
 		}
-		return m[t.YearDay()]
+		return m[t.YearDay()] || m[t.YearDay()-daysInYear(t.Year())-1]
 	}
 }
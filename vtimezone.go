@@ -0,0 +1,368 @@
+package rrule
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timezoneHorizon is when the expansion of VTIMEZONE observances stops. Their
+// last offset holds for every later time.
+var timezoneHorizon = time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// maxOnsets is the most onsets an observance may have before the horizon,
+// which bounds the memory a VTIMEZONE from untrusted input can take.
+const maxOnsets = 100000
+
+// ParseTimezones parses the VTIMEZONE components of an iCalendar object into
+// locations, keyed by TZID.
+//
+// The onsets of each STANDARD and DAYLIGHT observance are expanded from its
+// DTSTART, RRULEs, and RDATEs, through the year 2099. Each location changes to
+// an observance's TZOFFSETTO at its onsets, and uses its TZNAME as the
+// abbreviation, or the offset if it has none. Before the first onset, the
+// location uses the first onset's TZOFFSETFROM.
+func ParseTimezones(src []byte) (map[string]*time.Location, error) {
+	lines, err := parseContentLines(src)
+	if err != nil {
+		return nil, err
+	}
+
+	components, err := parseComponents(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return timezones(components)
+}
+
+// timezones parses the VTIMEZONEs among components and their descendants.
+func timezones(components []*component) (zones, error) {
+	z := zones{}
+
+	var walk func(components []*component) error
+	walk = func(components []*component) error {
+		for _, c := range components {
+			if c.name != "VTIMEZONE" {
+				if err := walk(c.children); err != nil {
+					return err
+				}
+				continue
+			}
+
+			tzid, loc, err := parseVTimezone(c)
+			if err != nil {
+				return err
+			}
+			z[tzid] = loc
+		}
+		return nil
+	}
+
+	if err := walk(components); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// splitTimezones removes the VTIMEZONE components from lines and parses them.
+func splitTimezones(lines []contentLine) ([]contentLine, zones, error) {
+	var rest, tz []contentLine
+	depth := 0
+
+	for _, line := range lines {
+		isBegin := line.name == "BEGIN"
+		isEnd := line.name == "END"
+
+		if depth == 0 && isBegin && strings.EqualFold(line.value, "VTIMEZONE") {
+			depth = 1
+			tz = append(tz, line)
+			continue
+		}
+		if depth == 0 {
+			rest = append(rest, line)
+			continue
+		}
+
+		tz = append(tz, line)
+		switch {
+		case isBegin:
+			depth++
+		case isEnd:
+			depth--
+		}
+	}
+
+	if len(tz) == 0 {
+		return rest, nil, nil
+	}
+
+	components, err := parseComponents(tz)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	z, err := timezones(components)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rest, z, nil
+}
+
+// observance is a STANDARD or DAYLIGHT component of a VTIMEZONE.
+type observance struct {
+	dst      bool
+	name     string
+	from, to int // offsets east of UTC, in seconds
+
+	// onsets is the recurrence of the local times at which the observance
+	// begins, written in UTC, since they are local to the from offset.
+	onsets *Recurrence
+}
+
+// transition is a change to an observance at an instant.
+type transition struct {
+	at  time.Time
+	obs *observance
+}
+
+// parseVTimezone parses a VTIMEZONE component into a location.
+func parseVTimezone(c *component) (string, *time.Location, error) {
+	tzid, ok := c.prop("TZID")
+	if !ok || tzid.value == "" {
+		return "", nil, errors.New("VTIMEZONE has no TZID")
+	}
+
+	var transitions []transition
+
+	for _, child := range c.children {
+		if child.name != "STANDARD" && child.name != "DAYLIGHT" {
+			continue
+		}
+
+		obs, err := parseObservance(child)
+		if err != nil {
+			return "", nil, fmt.Errorf("VTIMEZONE %s: %v", tzid.value, err)
+		}
+
+		it, err := obs.onsets.iterator()
+		if err != nil {
+			return "", nil, fmt.Errorf("VTIMEZONE %s: %v", tzid.value, err)
+		}
+
+		fromOffset := time.Duration(obs.from) * time.Second
+		onsets := 0
+		for onset, ok := it.next(); ok && onset.Before(timezoneHorizon); onset, ok = it.next() {
+			if onsets++; onsets > maxOnsets {
+				return "", nil, fmt.Errorf("VTIMEZONE %s: %s has more than %d onsets", tzid.value, child.name, maxOnsets)
+			}
+			transitions = append(transitions, transition{at: onset.Add(-fromOffset), obs: obs})
+		}
+	}
+
+	if len(transitions) == 0 {
+		return "", nil, fmt.Errorf("VTIMEZONE %s has no STANDARD or DAYLIGHT onsets", tzid.value)
+	}
+
+	slices.SortStableFunc(transitions, func(a, b transition) int {
+		return a.at.Compare(b.at)
+	})
+
+	loc, err := time.LoadLocationFromTZData(tzid.value, tzif(transitions))
+	if err != nil {
+		return "", nil, fmt.Errorf("VTIMEZONE %s: %v", tzid.value, err)
+	}
+	return tzid.value, loc, nil
+}
+
+// parseObservance parses a STANDARD or DAYLIGHT component.
+func parseObservance(c *component) (*observance, error) {
+	obs := &observance{dst: c.name == "DAYLIGHT"}
+
+	for _, prop := range []struct {
+		name   string
+		offset *int
+	}{{"TZOFFSETFROM", &obs.from}, {"TZOFFSETTO", &obs.to}} {
+		line, ok := c.prop(prop.name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no %s", c.name, prop.name)
+		}
+		offset, err := parseUTCOffset(line.value)
+		if err != nil {
+			return nil, err
+		}
+		*prop.offset = offset
+	}
+
+	if name, ok := c.prop("TZNAME"); ok {
+		obs.name = unescapeText(name.value)
+	}
+
+	// DTSTART and RDATE are local times, which are parsed as if they were in
+	// UTC to keep their clock readings.
+	onsets, err := parseRecurrenceLines(c.props, nil, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	if onsets.Dtstart.IsZero() {
+		return nil, fmt.Errorf("%s has no DTSTART", c.name)
+	}
+
+	// DTSTART is the first onset, even without an RRULE to repeat it.
+	onsets.RDates = append(onsets.RDates, onsets.Dtstart)
+
+	// UNTIL is an actual time, so it's moved to the local clock reading of
+	// the onsets. Offsets change at most daily, and more frequent onsets
+	// would only fill memory.
+	for i, rr := range onsets.RRules {
+		if rr.Frequency < Daily {
+			return nil, fmt.Errorf("%s RRULE must not repeat more often than DAILY", c.name)
+		}
+		if !rr.Until.IsZero() && !rr.UntilFloating && !rr.UntilDate {
+			rr.Until = rr.Until.UTC().Add(time.Duration(obs.from) * time.Second)
+			onsets.RRules[i] = rr
+		}
+	}
+
+	obs.onsets = onsets
+	return obs, nil
+}
+
+// parseUTCOffset parses a UTC-OFFSET value, such as -0500 or +053000, into
+// seconds east of UTC.
+func parseUTCOffset(str string) (int, error) {
+	if (len(str) != 5 && len(str) != 7) || (str[0] != '+' && str[0] != '-') {
+		return 0, fmt.Errorf("invalid UTC offset %q", str)
+	}
+
+	offset := 0
+	for i, unit := range []int{3600, 60, 1} {
+		start := 1 + 2*i
+		if start >= len(str) {
+			break
+		}
+		n, err := strconv.Atoi(str[start : start+2])
+		if err != nil || n < 0 || n > 59 {
+			return 0, fmt.Errorf("invalid UTC offset %q", str)
+		}
+		offset += n * unit
+	}
+
+	if str[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// offsetName returns an abbreviation for an offset without a name, in the
+// style of the IANA database, such as -05 or +0530.
+func offsetName(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+
+	name := fmt.Sprintf("%c%02d", sign, offset/3600)
+	if rest := offset % 3600; rest != 0 {
+		name += fmt.Sprintf("%02d", rest/60)
+		if rest%60 != 0 {
+			name += fmt.Sprintf("%02d", rest%60)
+		}
+	}
+	return name
+}
+
+// tzif encodes sorted transitions as version 2 TZif data, as described by RFC
+// 8536, which is the form in which the time package accepts them.
+func tzif(transitions []transition) []byte {
+	type localTime struct {
+		offset int
+		dst    bool
+		name   string
+	}
+
+	var types []localTime
+	var names []byte
+	nameIdx := map[string]int{}
+
+	typeOf := func(lt localTime) byte {
+		if lt.name == "" {
+			lt.name = offsetName(lt.offset)
+		}
+		if _, ok := nameIdx[lt.name]; !ok {
+			nameIdx[lt.name] = len(names)
+			names = append(append(names, lt.name...), 0)
+		}
+		// the first type is never shared; see below.
+		for i := 1; i < len(types); i++ {
+			if types[i] == lt {
+				return byte(i)
+			}
+		}
+		types = append(types, lt)
+		return byte(len(types) - 1)
+	}
+
+	// The first type applies before the first transition, so it's the offset
+	// being left, named after an observance that goes to it, if there is one.
+	// The time package only uses it there if no transition uses it too, and
+	// otherwise picks the first standard type, which is wrong when the first
+	// onset is of standard time.
+	first := transitions[0].obs
+	before := localTime{offset: first.from}
+	for _, t := range transitions {
+		if t.obs.to == first.from {
+			before.dst, before.name = t.obs.dst, t.obs.name
+			break
+		}
+	}
+	typeOf(before)
+
+	var times []int64
+	var indexes []byte
+	for _, t := range transitions {
+		idx := typeOf(localTime{offset: t.obs.to, dst: t.obs.dst, name: t.obs.name})
+		if n := len(times); n > 0 && times[n-1] == t.at.Unix() {
+			indexes[n-1] = idx
+			continue
+		}
+		times = append(times, t.at.Unix())
+		indexes = append(indexes, idx)
+	}
+
+	header := func(b []byte, timecnt, typecnt, charcnt int) []byte {
+		b = append(b, "TZif2"...)
+		b = append(b, make([]byte, 15)...)
+		for _, n := range []int{0, 0, 0, timecnt, typecnt, charcnt} {
+			b = binary.BigEndian.AppendUint32(b, uint32(n))
+		}
+		return b
+	}
+
+	// The version 1 block, with 32-bit times, is left empty.
+	b := header(nil, 0, 0, 0)
+	b = header(b, len(times), len(types), len(names))
+	for _, t := range times {
+		b = binary.BigEndian.AppendUint64(b, uint64(t))
+	}
+	b = append(b, indexes...)
+	for _, lt := range types {
+		b = binary.BigEndian.AppendUint32(b, uint32(int32(lt.offset)))
+		if lt.dst {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		b = append(b, byte(nameIdx[lt.name]))
+	}
+	b = append(b, names...)
+
+	// An empty footer leaves the last type in effect after the last
+	// transition.
+	return append(b, '\n', '\n')
+}
@@ -0,0 +1,321 @@
+package rrule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FormatTimezones returns the VTIMEZONE components for every location that
+// the recurrences write with a TZID, in the order they first appear. See
+// FormatTimezone.
+func FormatTimezones(firstYear, lastYear int, recurrences ...Recurrence) string {
+	b := &strings.Builder{}
+	seen := map[string]bool{}
+
+	for _, r := range recurrences {
+		for _, loc := range r.locations() {
+			if seen[loc.String()] {
+				continue
+			}
+			seen[loc.String()] = true
+			b.WriteString(FormatTimezone(loc, firstYear, lastYear))
+		}
+	}
+
+	return b.String()
+}
+
+// FormatTimezone returns a VTIMEZONE component describing loc from the
+// beginning of firstYear to the end of lastYear, using Go's zone data.
+//
+// Transitions that recur on the same day of the same month in consecutive
+// years, such as the second Sunday of March or the 25th of December, are
+// written as a STANDARD or DAYLIGHT observance with a yearly RRULE. A rule
+// that lasts through lastYear has no UNTIL, so it continues past the range.
+// Other transitions are written as RDATEs. The offset in effect at the
+// beginning of firstYear is written as an observance beginning then.
+func FormatTimezone(loc *time.Location, firstYear, lastYear int) string {
+	b := &strings.Builder{}
+	b.WriteString("BEGIN:VTIMEZONE\n")
+	fmt.Fprintf(b, "TZID:%s\n", loc)
+
+	start := time.Date(firstYear, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(lastYear+1, time.January, 1, 0, 0, 0, 0, loc)
+
+	// the offset in effect at the start begins there, since no transition in
+	// the range covers the times before the first of them.
+	name, offset := start.Zone()
+	writeObservance(b, zoneChange{
+		at:   start,
+		from: offset,
+		to:   offset,
+		name: name,
+		dst:  start.IsDST(),
+	}, nil, nil)
+
+	for _, obs := range compressTransitions(zoneTransitions(start, end), lastYear) {
+		if obs.rule != nil {
+			writeObservance(b, obs.changes[0], obs.rule, nil)
+		} else {
+			writeObservance(b, obs.changes[0], nil, obs.changes[1:])
+		}
+	}
+
+	b.WriteString("END:VTIMEZONE\n")
+	return b.String()
+}
+
+// locations returns the locations the recurrence writes with a TZID.
+func (r Recurrence) locations() []*time.Location {
+	if r.FloatingLocation || r.AllDay {
+		return nil
+	}
+
+	var locs []*time.Location
+	add := func(t time.Time) {
+		if t.IsZero() || t.Location() == time.UTC {
+			return
+		}
+		for _, loc := range locs {
+			if loc.String() == t.Location().String() {
+				return
+			}
+		}
+		locs = append(locs, t.Location())
+	}
+
+	add(r.Dtstart)
+	for _, t := range r.RDates {
+		add(t)
+	}
+	for _, p := range r.RPeriods {
+		add(p.Start)
+	}
+	for _, t := range r.ExDates {
+		add(t)
+	}
+	return locs
+}
+
+// zoneChange is a change of offset or abbreviation of a location.
+type zoneChange struct {
+	at       time.Time
+	from, to int
+	name     string
+	dst      bool
+}
+
+// local returns the clock reading at which c happens, in the offset it
+// changes from, written in UTC.
+func (c zoneChange) local() time.Time {
+	return c.at.UTC().Add(time.Duration(c.from) * time.Second)
+}
+
+// zoneTransitions returns the changes of the location of start between start
+// and end.
+func zoneTransitions(start, end time.Time) []zoneChange {
+	var changes []zoneChange
+
+	for t := start; t.Before(end); {
+		_, next := t.ZoneBounds()
+		if next.IsZero() || !next.Before(end) {
+			break
+		}
+
+		_, from := t.Zone()
+		name, to := next.Zone()
+		changes = append(changes, zoneChange{at: next, from: from, to: to, name: name, dst: next.IsDST()})
+		t = next
+	}
+
+	return changes
+}
+
+// zoneObservance is a run of changes to the same offset, along with the
+// yearly rule they follow, if any.
+type zoneObservance struct {
+	changes []zoneChange
+	rule    *RRule
+
+	// candidates are the rules every change of the run follows.
+	candidates []RRule
+}
+
+// compressTransitions groups changes into observances. Changes to the same
+// offset in consecutive years are collected while they follow a common yearly
+// rule. The remaining changes to each offset are gathered into one observance
+// without a rule.
+func compressTransitions(changes []zoneChange, lastYear int) []zoneObservance {
+	var runs []*zoneObservance
+
+	for _, c := range changes {
+		candidates := yearlyRules(c.local())
+
+		var extended bool
+		for _, run := range runs {
+			last := run.changes[len(run.changes)-1]
+			if !sameObservance(last, c) || last.local().Year()+1 != c.local().Year() {
+				continue
+			}
+
+			common := commonRules(run.candidates, candidates)
+			if len(common) == 0 {
+				continue
+			}
+
+			run.changes = append(run.changes, c)
+			run.candidates = common
+			extended = true
+			break
+		}
+
+		if !extended {
+			runs = append(runs, &zoneObservance{changes: []zoneChange{c}, candidates: candidates})
+		}
+	}
+
+	var observances []zoneObservance
+	var singles []*zoneObservance
+
+	for _, run := range runs {
+		if len(run.changes) > 1 {
+			rule := run.candidates[0]
+			last := run.changes[len(run.changes)-1]
+			if last.local().Year() < lastYear {
+				rule.Until = last.at.UTC()
+			}
+			run.rule = &rule
+			observances = append(observances, *run)
+			continue
+		}
+
+		c := run.changes[0]
+		var single *zoneObservance
+		for _, s := range singles {
+			if sameObservance(s.changes[0], c) {
+				single = s
+				break
+			}
+		}
+		if single == nil {
+			single = &zoneObservance{}
+			singles = append(singles, single)
+		}
+		single.changes = append(single.changes, c)
+	}
+
+	for _, single := range singles {
+		observances = append(observances, *single)
+	}
+	return observances
+}
+
+// sameObservance reports whether two changes could belong to one observance.
+func sameObservance(a, b zoneChange) bool {
+	return a.from == b.from && a.to == b.to && a.name == b.name && a.dst == b.dst
+}
+
+// yearlyRules returns the yearly rules that t follows, preferring a weekday
+// counted from the start of the month, then from the end, then the day of the
+// month. The time of day of every rule is that of t.
+func yearlyRules(t time.Time) []RRule {
+	months := []time.Month{t.Month()}
+
+	var rules []RRule
+	if n := (t.Day()-1)/7 + 1; n <= 4 {
+		rules = append(rules, RRule{
+			Frequency:  Yearly,
+			ByMonths:   months,
+			ByWeekdays: []QualifiedWeekday{{N: n, WD: t.Weekday()}},
+		})
+	}
+	if t.AddDate(0, 0, 7).Month() != t.Month() {
+		rules = append(rules, RRule{
+			Frequency:  Yearly,
+			ByMonths:   months,
+			ByWeekdays: []QualifiedWeekday{{N: -1, WD: t.Weekday()}},
+		})
+	}
+	rules = append(rules, RRule{
+		Frequency:   Yearly,
+		ByMonths:    months,
+		ByMonthDays: []int{t.Day()},
+	})
+
+	for i := range rules {
+		rules[i].Dtstart = t
+	}
+	return rules
+}
+
+// commonRules returns the rules of a that are also in b, comparing the month,
+// weekday, month day, and time of day of each.
+func commonRules(a, b []RRule) []RRule {
+	var common []RRule
+	for _, ra := range a {
+		for _, rb := range b {
+			if ra.ByMonths[0] != rb.ByMonths[0] ||
+				ra.Dtstart.Hour() != rb.Dtstart.Hour() ||
+				ra.Dtstart.Minute() != rb.Dtstart.Minute() ||
+				ra.Dtstart.Second() != rb.Dtstart.Second() ||
+				len(ra.ByWeekdays) != len(rb.ByWeekdays) ||
+				len(ra.ByMonthDays) != len(rb.ByMonthDays) {
+				continue
+			}
+			if len(ra.ByWeekdays) > 0 && ra.ByWeekdays[0] != rb.ByWeekdays[0] {
+				continue
+			}
+			if len(ra.ByMonthDays) > 0 && ra.ByMonthDays[0] != rb.ByMonthDays[0] {
+				continue
+			}
+			common = append(common, ra)
+		}
+	}
+	return common
+}
+
+// writeObservance writes a STANDARD or DAYLIGHT component that begins with
+// first, repeats by rule if it is not nil, and also begins at each of rdates.
+func writeObservance(b *strings.Builder, first zoneChange, rule *RRule, rdates []zoneChange) {
+	kind := "STANDARD"
+	if first.dst {
+		kind = "DAYLIGHT"
+	}
+
+	fmt.Fprintf(b, "BEGIN:%s\n", kind)
+	fmt.Fprintf(b, "DTSTART:%s\n", first.local().Format(rfc5545WithoutOffset))
+	if rule != nil {
+		rule.Dtstart = time.Time{}
+		fmt.Fprintf(b, "RRULE:%s\n", rule)
+	}
+	if len(rdates) > 0 {
+		values := make([]string, len(rdates))
+		for i, c := range rdates {
+			values[i] = c.local().Format(rfc5545WithoutOffset)
+		}
+		fmt.Fprintf(b, "RDATE:%s\n", strings.Join(values, ","))
+	}
+	fmt.Fprintf(b, "TZOFFSETFROM:%s\n", formatUTCOffset(first.from))
+	fmt.Fprintf(b, "TZOFFSETTO:%s\n", formatUTCOffset(first.to))
+	if first.name != "" {
+		fmt.Fprintf(b, "TZNAME:%s\n", first.name)
+	}
+	fmt.Fprintf(b, "END:%s\n", kind)
+}
+
+// formatUTCOffset returns the UTC-OFFSET value of an offset in seconds, such
+// as -0500 or +053000.
+func formatUTCOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+
+	str := fmt.Sprintf("%c%02d%02d", sign, offset/3600, offset%3600/60)
+	if offset%60 != 0 {
+		str += fmt.Sprintf("%02d", offset%60)
+	}
+	return str
+}
@@ -0,0 +1,148 @@
+package rrule
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatTimezoneNewYork(t *testing.T) {
+	assert.Equal(t, "BEGIN:VTIMEZONE\n"+
+		"TZID:America/New_York\n"+
+		"BEGIN:STANDARD\n"+
+		"DTSTART:20000101T000000\n"+
+		"TZOFFSETFROM:-0500\n"+
+		"TZOFFSETTO:-0500\n"+
+		"TZNAME:EST\n"+
+		"END:STANDARD\n"+
+		"BEGIN:DAYLIGHT\n"+
+		"DTSTART:20000402T020000\n"+
+		"RRULE:FREQ=YEARLY;UNTIL=20060402T070000Z;BYDAY=1SU;BYMONTH=4\n"+
+		"TZOFFSETFROM:-0500\n"+
+		"TZOFFSETTO:-0400\n"+
+		"TZNAME:EDT\n"+
+		"END:DAYLIGHT\n"+
+		"BEGIN:STANDARD\n"+
+		"DTSTART:20001029T020000\n"+
+		"RRULE:FREQ=YEARLY;UNTIL=20061029T060000Z;BYDAY=-1SU;BYMONTH=10\n"+
+		"TZOFFSETFROM:-0400\n"+
+		"TZOFFSETTO:-0500\n"+
+		"TZNAME:EST\n"+
+		"END:STANDARD\n"+
+		"BEGIN:DAYLIGHT\n"+
+		"DTSTART:20070311T020000\n"+
+		"RRULE:FREQ=YEARLY;BYDAY=2SU;BYMONTH=3\n"+
+		"TZOFFSETFROM:-0500\n"+
+		"TZOFFSETTO:-0400\n"+
+		"TZNAME:EDT\n"+
+		"END:DAYLIGHT\n"+
+		"BEGIN:STANDARD\n"+
+		"DTSTART:20071104T020000\n"+
+		"RRULE:FREQ=YEARLY;BYDAY=1SU;BYMONTH=11\n"+
+		"TZOFFSETFROM:-0400\n"+
+		"TZOFFSETTO:-0500\n"+
+		"TZNAME:EST\n"+
+		"END:STANDARD\n"+
+		"END:VTIMEZONE\n", FormatTimezone(NewYork(), 2000, 2030))
+}
+
+func TestFormatTimezoneRoundTrip(t *testing.T) {
+	for _, name := range []string{
+		"America/New_York",
+		"Europe/Berlin",
+		"Australia/Sydney",
+		"America/Sao_Paulo",
+		"Asia/Kolkata",
+		"Europe/Moscow",
+		"Africa/Casablanca",
+	} {
+		t.Run(name, func(t *testing.T) {
+			loc, err := time.LoadLocation(name)
+			require.NoError(t, err)
+
+			src := FormatTimezone(loc, 1970, 2037)
+			zones, err := ParseTimezones([]byte(src))
+			require.NoError(t, err)
+			parsed := zones[name]
+			require.NotNil(t, parsed, src)
+
+			for tt := time.Date(1970, 1, 1, 0, 0, 0, 0, loc); tt.Year() < 2038; tt = tt.Add(time.Hour) {
+				wantName, wantOffset := tt.Zone()
+				gotName, gotOffset := tt.In(parsed).Zone()
+				if wantName != gotName || wantOffset != gotOffset {
+					t.Fatalf("at %s: got %s %d, want %s %d\n%s", tt, gotName, gotOffset, wantName, wantOffset, src)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatTimezoneFixed(t *testing.T) {
+	assert.Equal(t, "BEGIN:VTIMEZONE\n"+
+		"TZID:Fixed\n"+
+		"BEGIN:STANDARD\n"+
+		"DTSTART:20200101T000000\n"+
+		"TZOFFSETFROM:+0530\n"+
+		"TZOFFSETTO:+0530\n"+
+		"TZNAME:Fixed\n"+
+		"END:STANDARD\n"+
+		"END:VTIMEZONE\n", FormatTimezone(time.FixedZone("Fixed", 5*60*60+30*60), 2020, 2030))
+
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN:VTIMEZONE\n"+
+		"TZID:Asia/Kolkata\n"+
+		"BEGIN:STANDARD\n"+
+		"DTSTART:20200101T000000\n"+
+		"TZOFFSETFROM:+0530\n"+
+		"TZOFFSETTO:+0530\n"+
+		"TZNAME:IST\n"+
+		"END:STANDARD\n"+
+		"END:VTIMEZONE\n", FormatTimezone(kolkata, 2020, 2030))
+}
+
+func TestFormatTimezones(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	rs := []Recurrence{
+		{
+			Dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, NewYork()),
+			ExDates: []time.Time{time.Date(2024, 1, 2, 9, 0, 0, 0, berlin)},
+			RDates:  []time.Time{time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		},
+		{Dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, berlin)},
+		{Dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, NewYork()), FloatingLocation: true},
+		{Dtstart: time.Date(2024, 1, 1, 0, 0, 0, 0, NewYork()), AllDay: true},
+	}
+
+	src := FormatTimezones(2020, 2030, rs...)
+	assert.Equal(t, 2, strings.Count(src, "BEGIN:VTIMEZONE"))
+	assert.True(t, strings.Index(src, "TZID:America/New_York") < strings.Index(src, "TZID:Europe/Berlin"))
+
+	r, err := ParseRecurrence([]byte(src+rs[0].String()), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", r.Dtstart.Location().String())
+	assert.True(t, rs[0].Dtstart.Equal(r.Dtstart))
+	assert.True(t, rs[0].ExDates[0].Equal(r.ExDates[0]))
+
+	assert.Empty(t, FormatTimezones(2020, 2030, Recurrence{Dtstart: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}))
+}
+
+func TestFormatUTCOffset(t *testing.T) {
+	for offset, str := range map[int]string{
+		-5 * 60 * 60:     "-0500",
+		0:                "+0000",
+		5*60*60 + 30*60:  "+0530",
+		-(34*60 + 15):    "-003415",
+		12*60*60 + 45*60: "+1245",
+	} {
+		assert.Equal(t, str, formatUTCOffset(offset))
+		parsed, err := parseUTCOffset(str)
+		require.NoError(t, err)
+		assert.Equal(t, offset, parsed)
+	}
+}
@@ -0,0 +1,255 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcNewYork is the America/New_York example of section 3.6.5 of RFC 5545.
+const rfcNewYork = "BEGIN:VTIMEZONE\n" +
+	"TZID:America/New_York\n" +
+	"LAST-MODIFIED:20050809T050000Z\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:19670430T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=-1SU;UNTIL=19730429T070000Z\n" +
+	"TZOFFSETFROM:-0500\n" +
+	"TZOFFSETTO:-0400\n" +
+	"TZNAME:EDT\n" +
+	"END:DAYLIGHT\n" +
+	"BEGIN:STANDARD\n" +
+	"DTSTART:19671029T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU;UNTIL=20061029T060000Z\n" +
+	"TZOFFSETFROM:-0400\n" +
+	"TZOFFSETTO:-0500\n" +
+	"TZNAME:EST\n" +
+	"END:STANDARD\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:19740106T020000\n" +
+	"RDATE:19750223T020000\n" +
+	"TZOFFSETFROM:-0500\n" +
+	"TZOFFSETTO:-0400\n" +
+	"TZNAME:EDT\n" +
+	"END:DAYLIGHT\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:19760425T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=-1SU;UNTIL=19860427T070000Z\n" +
+	"TZOFFSETFROM:-0500\n" +
+	"TZOFFSETTO:-0400\n" +
+	"TZNAME:EDT\n" +
+	"END:DAYLIGHT\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:19870405T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU;UNTIL=20060402T070000Z\n" +
+	"TZOFFSETFROM:-0500\n" +
+	"TZOFFSETTO:-0400\n" +
+	"TZNAME:EDT\n" +
+	"END:DAYLIGHT\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:20070311T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\n" +
+	"TZOFFSETFROM:-0500\n" +
+	"TZOFFSETTO:-0400\n" +
+	"TZNAME:EDT\n" +
+	"END:DAYLIGHT\n" +
+	"BEGIN:STANDARD\n" +
+	"DTSTART:20071104T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU\n" +
+	"TZOFFSETFROM:-0400\n" +
+	"TZOFFSETTO:-0500\n" +
+	"TZNAME:EST\n" +
+	"END:STANDARD\n" +
+	"END:VTIMEZONE\n"
+
+// outlookPacific is a VTIMEZONE in the style of Outlook, with a Windows zone
+// name and no TZNAME.
+const outlookPacific = "BEGIN:VTIMEZONE\n" +
+	"TZID:Pacific Standard Time\n" +
+	"BEGIN:STANDARD\n" +
+	"DTSTART:16010101T020000\n" +
+	"TZOFFSETFROM:-0700\n" +
+	"TZOFFSETTO:-0800\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=1SU;BYMONTH=11\n" +
+	"END:STANDARD\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:16010101T020000\n" +
+	"TZOFFSETFROM:-0800\n" +
+	"TZOFFSETTO:-0700\n" +
+	"RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=2SU;BYMONTH=3\n" +
+	"END:DAYLIGHT\n" +
+	"END:VTIMEZONE\n"
+
+// sydney is Australia/Sydney since 2008, whose first onset is of standard
+// time, as for every zone of the southern hemisphere.
+const sydney = "BEGIN:VTIMEZONE\n" +
+	"TZID:Australia/Sydney\n" +
+	"BEGIN:STANDARD\n" +
+	"DTSTART:20080406T030000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU\n" +
+	"TZOFFSETFROM:+1100\n" +
+	"TZOFFSETTO:+1000\n" +
+	"TZNAME:AEST\n" +
+	"END:STANDARD\n" +
+	"BEGIN:DAYLIGHT\n" +
+	"DTSTART:20081005T020000\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=1SU\n" +
+	"TZOFFSETFROM:+1000\n" +
+	"TZOFFSETTO:+1100\n" +
+	"TZNAME:AEDT\n" +
+	"END:DAYLIGHT\n" +
+	"END:VTIMEZONE\n"
+
+func TestParseTimezonesRFC(t *testing.T) {
+	zones, err := ParseTimezones([]byte(rfcNewYork))
+	require.NoError(t, err)
+	require.Contains(t, zones, "America/New_York")
+
+	parsed := zones["America/New_York"]
+	assert.Equal(t, "America/New_York", parsed.String())
+
+	ny := NewYork()
+	for tt := time.Date(1967, 11, 1, 0, 0, 0, 0, time.UTC); tt.Year() < 2040; tt = tt.Add(time.Hour) {
+		wantName, wantOffset := tt.In(ny).Zone()
+		gotName, gotOffset := tt.In(parsed).Zone()
+		if wantName != gotName || wantOffset != gotOffset {
+			t.Fatalf("at %s: got %s %d, want %s %d", tt, gotName, gotOffset, wantName, wantOffset)
+		}
+	}
+}
+
+func TestParseTimezonesOutlook(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	zones, err := ParseTimezones([]byte("BEGIN:VCALENDAR\n" + outlookPacific + "END:VCALENDAR\n"))
+	require.NoError(t, err)
+	parsed := zones["Pacific Standard Time"]
+	require.NotNil(t, parsed)
+
+	for tt := time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC); tt.Year() < 2040; tt = tt.Add(time.Hour) {
+		_, wantOffset := tt.In(la).Zone()
+		_, gotOffset := tt.In(parsed).Zone()
+		if wantOffset != gotOffset {
+			t.Fatalf("at %s: got %d, want %d", tt, gotOffset, wantOffset)
+		}
+	}
+
+	name, _ := time.Date(2024, 1, 1, 0, 0, 0, 0, parsed).Zone()
+	assert.Equal(t, "-08", name)
+	name, _ = time.Date(2024, 7, 1, 0, 0, 0, 0, parsed).Zone()
+	assert.Equal(t, "-07", name)
+
+	// the last offset holds after the horizon.
+	_, offset := time.Date(2200, 7, 1, 0, 0, 0, 0, parsed).Zone()
+	assert.Equal(t, -8*60*60, offset)
+}
+
+func TestParseTimezonesSouthern(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+
+	zones, err := ParseTimezones([]byte(sydney))
+	require.NoError(t, err)
+	parsed := zones["Australia/Sydney"]
+	require.NotNil(t, parsed)
+
+	// before the first onset, the offset is the TZOFFSETFROM of that onset.
+	for tt := time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC); tt.Year() < 2040; tt = tt.Add(time.Hour) {
+		wantName, wantOffset := tt.In(loc).Zone()
+		gotName, gotOffset := tt.In(parsed).Zone()
+		if wantName != gotName || wantOffset != gotOffset {
+			t.Fatalf("at %s: got %s %d, want %s %d", tt, gotName, gotOffset, wantName, wantOffset)
+		}
+	}
+	name, offset := time.Date(1990, 1, 1, 0, 0, 0, 0, parsed).Zone()
+	assert.Equal(t, "AEDT", name)
+	assert.Equal(t, 11*60*60, offset)
+
+	// as for a zone written by FormatTimezones.
+	for _, name := range []string{"Australia/Sydney", "America/Sao_Paulo", "America/Santiago", "Australia/Lord_Howe"} {
+		loc, err := time.LoadLocation(name)
+		require.NoError(t, err)
+
+		r := Recurrence{Dtstart: time.Date(2015, 1, 15, 9, 0, 0, 0, loc)}
+		parsed, err := ParseRecurrence([]byte(FormatTimezones(2015, 2030, r)+r.String()), time.UTC)
+		require.NoError(t, err)
+		assert.Equal(t, r.Dtstart.Format(time.RFC3339), parsed.Dtstart.Format(time.RFC3339), name)
+	}
+}
+
+func TestParseRecurrenceVTimezone(t *testing.T) {
+	loadLocation := LoadLocation
+	defer func() { LoadLocation = loadLocation }()
+	LoadLocation = func(name string) (*time.Location, error) {
+		t.Errorf("unexpected LoadLocation(%q)", name)
+		return loadLocation(name)
+	}
+
+	src := outlookPacific +
+		"BEGIN:VEVENT\n" +
+		"DTSTART;TZID=\"Pacific Standard Time\":20240301T090000\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=3\n" +
+		"EXDATE;TZID=\"Pacific Standard Time\":20240315T090000\n" +
+		"END:VEVENT\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Pacific Standard Time", r.Dtstart.Location().String())
+	assert.Len(t, r.RRules, 1)
+	assert.Equal(t, []string{
+		"2024-03-01T09:00:00-08:00",
+		"2024-03-08T09:00:00-08:00",
+	}, rfcAll(All(r.Iterator(), 0)))
+
+	events, err := ParseCalendar([]byte("BEGIN:VCALENDAR\n"+src+"END:VCALENDAR\n"), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{
+		"2024-03-01T09:00:00-08:00",
+		"2024-03-08T09:00:00-08:00",
+	}, rfcAll(All(events[0].Recurrence.Iterator(), 0)))
+}
+
+func TestParseUTCOffset(t *testing.T) {
+	for str, offset := range map[string]int{
+		"-0500":   -5 * 60 * 60,
+		"+0000":   0,
+		"+0530":   5*60*60 + 30*60,
+		"-003415": -(34*60 + 15),
+	} {
+		got, err := parseUTCOffset(str)
+		require.NoError(t, err, str)
+		assert.Equal(t, offset, got, str)
+	}
+
+	for _, str := range []string{"", "0500", "-05", "-05000", "+0560", "-05x0"} {
+		_, err := parseUTCOffset(str)
+		assert.Error(t, err, str)
+	}
+
+	assert.Equal(t, "-05", offsetName(-5*60*60))
+	assert.Equal(t, "+0530", offsetName(5*60*60+30*60))
+	assert.Equal(t, "-003415", offsetName(-(34*60 + 15)))
+}
+
+func TestParseTimezonesErrors(t *testing.T) {
+	// the last observances repeat more often than daily or have too many
+	// onsets, and are rejected rather than expanded.
+	for _, src := range []string{
+		"BEGIN:VTIMEZONE\nBEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0000\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0000\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0000\nTZOFFSETTO:5\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0100\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nDTSTART:19700101T000000\nRRULE:FREQ=MINUTELY\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0100\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nDTSTART:19700101T000000\nRRULE:FREQ=HOURLY;INTERVAL=24\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0100\nEND:STANDARD\nEND:VTIMEZONE\n",
+		"BEGIN:VTIMEZONE\nTZID:x\nBEGIN:STANDARD\nDTSTART:16000101T000000\nRRULE:FREQ=DAILY\nTZOFFSETFROM:+0000\nTZOFFSETTO:+0100\nEND:STANDARD\nEND:VTIMEZONE\n",
+	} {
+		_, err := ParseTimezones([]byte(src))
+		assert.Error(t, err, src)
+	}
+}
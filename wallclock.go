@@ -0,0 +1,337 @@
+package rrule
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// NonexistentBehavior specifies how to place an occurrence at a local time
+// that does not exist, because clocks were set forward past it.
+type NonexistentBehavior int
+
+const (
+	// OffsetNonexistent uses the UTC offset from before clocks were set
+	// forward, as RFC 5545 does for DATE-TIME values. So if clocks skip from
+	// 02:00 to 03:00, 02:30 becomes 03:30.
+	OffsetNonexistent NonexistentBehavior = iota
+
+	// ShiftNonexistent moves the occurrence forward to the moment clocks
+	// were set forward. So if clocks skip from 02:00 to 03:00, 02:30 becomes
+	// 03:00.
+	ShiftNonexistent
+
+	// SkipNonexistent omits the occurrence.
+	SkipNonexistent
+)
+
+// AmbiguousBehavior specifies how to place an occurrence at a local time that
+// occurs twice, because clocks were set back over it.
+type AmbiguousBehavior int
+
+const (
+	// DefaultAmbiguous is BothAmbiguous for HOURLY, MINUTELY, and SECONDLY
+	// patterns, so that like times a fixed duration apart, their
+	// occurrences continue through the hour clocks repeat. It is
+	// FirstAmbiguous for other patterns.
+	DefaultAmbiguous AmbiguousBehavior = iota
+
+	// FirstAmbiguous uses the first occurrence of the local time, as RFC
+	// 5545 does for DATE-TIME values.
+	FirstAmbiguous
+
+	// SecondAmbiguous uses the second occurrence of the local time.
+	SecondAmbiguous
+
+	// BothAmbiguous uses both occurrences of the local time.
+	BothAmbiguous
+)
+
+// ambiguousBehavior returns how the pattern places ambiguous times, which is
+// not DefaultAmbiguous.
+func (rrule RRule) ambiguousBehavior() AmbiguousBehavior {
+	switch {
+	case rrule.AmbiguousBehavior != DefaultAmbiguous:
+		return rrule.AmbiguousBehavior
+	case rrule.Frequency < Daily:
+		return BothAmbiguous
+	}
+	return FirstAmbiguous
+}
+
+// wallClock returns the local time of t as if it were in UTC.
+func wallClock(t time.Time) time.Time {
+	_, offset := t.Zone()
+	return time.Unix(t.Unix()+int64(offset), int64(t.Nanosecond())).UTC()
+}
+
+// changesAfter reports whether the clocks of the location of t change at any
+// time after t.
+func changesAfter(t time.Time) bool {
+	_, end := t.ZoneBounds()
+	return !end.IsZero()
+}
+
+// placement places wall-clock times, which patterns are expanded in as if in
+// UTC, at the instants the clocks of loc read them.
+type placement struct {
+	loc         *time.Location
+	nonexistent NonexistentBehavior
+	ambiguous   AmbiguousBehavior
+
+	// wall-clock times from lo to hi, in Unix seconds, occur exactly once,
+	// at offset seconds ahead of UTC. They are those of the zone last
+	// looked up, which saves looking it up again for nearby times.
+	lo, hi int64
+	offset int64
+}
+
+// resolve returns the instants at which the clocks of loc read the wall-clock
+// time w. There are n of them: one, first, unless clocks were set back over w,
+// in which case there are two, first and second. If clocks were set forward
+// past w, n is zero, first is w at the UTC offset from before clocks were set
+// forward, and second is the moment they were.
+func (p *placement) resolve(w time.Time) (first, second time.Time, n int) {
+	secs, nsec := w.Unix(), int64(w.Nanosecond())
+	if p.lo <= secs && secs < p.hi {
+		return time.Unix(secs-p.offset, nsec).In(p.loc), time.Time{}, 1
+	}
+
+	// guess lies in a zone near w, whose neighbors may read w instead.
+	_, guessOffset := time.Unix(secs, 0).In(p.loc).Zone()
+	guess := time.Unix(secs-int64(guessOffset), 0).In(p.loc)
+	_, offset := guess.Zone()
+	start, end := guess.ZoneBounds()
+
+	before, after := offset, offset
+	if !start.IsZero() {
+		_, before = start.Add(-time.Second).Zone()
+	}
+	if !end.IsZero() {
+		_, after = end.Zone()
+	}
+
+	// each zone reads w at w less its offset, if that is within the zone.
+	var found [3]int64
+	if !start.IsZero() && secs-int64(before) < start.Unix() {
+		found[n] = secs - int64(before)
+		n++
+	}
+	inZone := (start.IsZero() || secs-int64(offset) >= start.Unix()) &&
+		(end.IsZero() || secs-int64(offset) < end.Unix())
+	if inZone {
+		found[n] = secs - int64(offset)
+		n++
+	}
+	if !end.IsZero() && secs-int64(after) >= end.Unix() {
+		found[n] = secs - int64(after)
+		n++
+	}
+
+	switch {
+	case n == 0 && secs-int64(offset) < start.Unix():
+		return time.Unix(secs-int64(before), nsec).In(p.loc), start, 0
+	case n == 0:
+		return time.Unix(secs-int64(offset), nsec).In(p.loc), end, 0
+	case n == 1 && inZone:
+		p.lo, p.hi, p.offset = math.MinInt64, math.MaxInt64, int64(offset)
+		if !start.IsZero() {
+			p.lo = start.Unix() + int64(max(offset, before))
+		}
+		if !end.IsZero() {
+			p.hi = end.Unix() + int64(min(offset, after))
+		}
+	}
+
+	first = time.Unix(found[0], nsec).In(p.loc)
+	if n > 1 {
+		return first, time.Unix(found[1], nsec).In(p.loc), 2
+	}
+	return first, time.Time{}, 1
+}
+
+// place appends the instants at which the clocks of loc read the wall-clock
+// time w to dst, according to how nonexistent and ambiguous times are placed.
+func (p *placement) place(dst []time.Time, w time.Time) []time.Time {
+	first, second, n := p.resolve(w)
+	switch {
+	case n == 0 && p.nonexistent == ShiftNonexistent:
+		return append(dst, second)
+	case n == 0 && p.nonexistent == SkipNonexistent:
+		return dst
+	case n == 2 && p.ambiguous == SecondAmbiguous:
+		return append(dst, second)
+	case n == 2 && p.ambiguous == BothAmbiguous:
+		return append(dst, first, second)
+	}
+	return append(dst, first)
+}
+
+// earliest returns an instant no later than the first at which a time of w
+// or later may be placed.
+func (p *placement) earliest(w time.Time) time.Time {
+	first, second, n := p.resolve(w)
+	if n == 0 {
+		return second
+	}
+	return first
+}
+
+// latestBefore returns an instant no earlier than the last at which a time
+// before w may be placed.
+func (p *placement) latestBefore(w time.Time) time.Time {
+	first, second, n := p.resolve(w)
+	switch n {
+	case 0:
+		return first
+	case 2:
+		return second.Add(-time.Nanosecond)
+	}
+
+	// times that clocks skipped just before w may be placed after it.
+	if start, _ := first.ZoneBounds(); !start.IsZero() {
+		_, before := start.Add(-time.Nanosecond).Zone()
+		_, offset := first.Zone()
+		if end := start.Add(time.Duration(offset-before) * time.Second); end.After(first) {
+			return end
+		}
+	}
+	return first.Add(-time.Nanosecond)
+}
+
+// place makes an iterator for rrule, whose periods were expanded in wall-clock
+// time, produce the instants at which the clocks of the location of start read
+// those times.
+func (i *iterator) place(rrule RRule, start time.Time) {
+	p := &placement{
+		loc:         start.Location(),
+		nonexistent: rrule.NonexistentBehavior,
+		ambiguous:   rrule.ambiguousBehavior(),
+	}
+	i.placement = p
+
+	variations, periodOf := i.variations, i.periodOf
+	i.wallPeriodOf = periodOf
+	i.variations = func(e *expansion, t time.Time) []time.Time {
+		tt := variations(e, t)
+		out := e.out()
+		for _, w := range tt {
+			out = p.place(out, w)
+		}
+		slices.SortFunc(out, time.Time.Compare)
+		e.swap(slices.CompactFunc(out, time.Time.Equal))
+		return e.tt
+	}
+	i.periodOf = func(t time.Time) int {
+		return periodOf(wallClock(t.In(p.loc)))
+	}
+
+	if rrule.Frequency >= Daily || !changesAfter(start) {
+		return
+	}
+
+	// the periods of HOURLY, MINUTELY, and SECONDLY patterns are shorter
+	// than the times clocks skip or repeat, so periods before that of start
+	// may produce times after it, and shifted nonexistent times are those of
+	// the next period.
+	i.period = min(i.period, i.periodFrom(start))
+	i.repeats = rrule.NonexistentBehavior == ShiftNonexistent
+
+	unit := time.Second
+	switch rrule.Frequency {
+	case Minutely:
+		unit = time.Minute
+	case Hourly:
+		unit = time.Hour
+	}
+
+	// a period whose times clocks repeat is two elapsed periods, which
+	// BYSETPOS selects from separately.
+	i.setPosUnit = unit
+
+	// nonexistent times placed at the offset from before clocks were set
+	// forward, and the second occurrences of ambiguous times, come after the
+	// times of later periods. Times are then held back until no later period
+	// can produce an earlier one.
+	if rrule.NonexistentBehavior == OffsetNonexistent || p.ambiguous == BothAmbiguous {
+		key := i.key
+		i.bounds = func(n int) (earliest, latest time.Time) {
+			from, _ := key(n)
+			to, _ := key(n + 1)
+			return p.earliest(from.Truncate(unit)), p.latestBefore(to.Truncate(unit))
+		}
+	}
+}
+
+// occurrence returns the occurrence of the nth period of an arithmetic
+// iterator, which is its key time placed in the location of the pattern.
+func (i *iterator) occurrence(n int) (time.Time, bool) {
+	key, ok := i.key(n)
+	if !ok {
+		return key, false
+	}
+
+	var buf [2]time.Time
+	placed := i.placement.place(buf[:0], key)
+	if len(placed) == 0 {
+		return time.Time{}, false
+	}
+	return placed[0], true
+}
+
+// periodsOf returns the range of periods whose times may be placed at t. That
+// is the period of the time clocks read at t, unless clocks were set forward
+// shortly before t. Then the times they skipped may be placed at t too.
+func (i *iterator) periodsOf(t time.Time) (first, last int) {
+	last = i.periodOf(t)
+	if i.placement == nil {
+		return last, last
+	}
+
+	t = t.In(i.placement.loc)
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return last, last
+	}
+
+	_, before := start.Add(-time.Nanosecond).Zone()
+	_, offset := t.Zone()
+	skipped := time.Duration(offset-before) * time.Second
+	if skipped <= 0 || !t.Before(start.Add(skipped)) {
+		return last, last
+	}
+	return i.wallPeriodOf(wallClock(t).Add(-skipped)), last
+}
+
+// periodFrom returns the first period that may produce a time at or after t.
+// That is the one periodsOf begins with, unless clocks are next set back to
+// a time before the one they read at t.
+func (i *iterator) periodFrom(t time.Time) int {
+	first, _ := i.periodsOf(t)
+	if i.placement == nil {
+		return first
+	}
+
+	t = t.In(i.placement.loc)
+	if _, end := t.ZoneBounds(); !end.IsZero() && wallClock(end).Before(wallClock(t)) {
+		return min(first, i.periodOf(end))
+	}
+	return first
+}
+
+// periodUpTo returns the last period that may produce a time at or before t.
+// That is the period of t, unless clocks were last set back from a time after
+// the one they read at t.
+func (i *iterator) periodUpTo(t time.Time) int {
+	if i.placement == nil {
+		return i.periodOf(t)
+	}
+
+	t = t.In(i.placement.loc)
+	if start, _ := t.ZoneBounds(); !start.IsZero() {
+		if before := start.Add(-time.Nanosecond); wallClock(before).After(wallClock(t)) {
+			return i.periodOf(before)
+		}
+	}
+	return i.periodOf(t)
+}
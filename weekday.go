@@ -46,49 +46,44 @@ func WeekdayString(wd time.Weekday) string {
 	return wdStr
 }
 
-// weekdaysInyear returns either all the weekdays in a year, or a slice with one time, which is
-// the nth weekday specified by wd. If wd.N is invalid (e.g. no 53rd week in a year), ib defines
-// whether to return an empty set (OmitInvalid), the last weekday of the given year, or the first
-// weekday of the following year.
-func weekdaysInYear(t time.Time, wd QualifiedWeekday, ib InvalidBehavior) []time.Time {
-	allWDs := make([]time.Time, 0, 5)
-
+// weekdaysInYear appends to dst either all the weekdays in a year, or the nth
+// weekday specified by wd. If wd.N is invalid (e.g. no 53rd week in a year), ib
+// defines whether to append nothing (OmitInvalid), the last weekday of the given
+// year, or the first weekday of the following year.
+func weekdaysInYear(dst []time.Time, t time.Time, wd QualifiedWeekday, ib InvalidBehavior) []time.Time {
 	// start on first of year
-	day := time.Date(t.Year(), 1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	first := time.Date(t.Year(), 1, 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
 
-	// scan til the first relevant weekday of the year
-	for day.Weekday() != wd.WD {
-		day = day.AddDate(0, 0, 1)
-	}
+	// advance to the first relevant weekday of the year
+	first = forwardToWeekday(first, wd.WD)
 
-	// scan over every week of the year
-	for {
-		allWDs = append(allWDs, day)
-		day = day.AddDate(0, 0, 7)
-		if day.Year() != t.Year() {
-			break
-		}
+	// count the instances of the weekday in the year
+	daysInYear := time.Date(t.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	count := (daysInYear-first.YearDay())/7 + 1
+
+	nth := func(idx int) time.Time {
+		return first.AddDate(0, 0, idx*7)
 	}
 
 	if wd.N == 0 {
 		// no index specified, return all.
-		return allWDs
+		for idx := 0; idx < count; idx++ {
+			dst = append(dst, nth(idx))
+		}
+		return dst
 	}
 
 	if wd.N > 0 {
 		// positive index specified. count to the correct instance
-		if wd.N > len(allWDs) {
+		if wd.N > count {
 			switch ib {
-			case OmitInvalid:
-				return nil
-			case PrevInvalid:
-				idx := len(allWDs) - 1
-				return allWDs[idx:idx]
+			case OmitInvalid, PrevInvalid:
+				return dst
 			case NextInvalid:
-				return []time.Time{allWDs[len(allWDs)-1].AddDate(0, 0, 7)}
+				return append(dst, nth(count))
 			}
 		}
-		return []time.Time{allWDs[wd.N-1]}
+		return append(dst, nth(wd.N-1))
 	}
 
 	// negative index specified. count backwards to the correct instance
@@ -102,20 +97,18 @@ func weekdaysInYear(t time.Time, wd QualifiedWeekday, ib InvalidBehavior) []time
 	//	- the index becomes 1, which is the third from last
 	// -7 in a list of 4 ..
 	//	- the index becomes -3, which should trigger invalid behavior
-	idx := len(allWDs) + wd.N
+	idx := count + wd.N
 
-	if idx < 0 || idx > len(allWDs) {
+	if idx < 0 {
 		switch ib {
-		case OmitInvalid:
-			return nil
+		case OmitInvalid, NextInvalid:
+			return dst
 		case PrevInvalid:
-			return []time.Time{allWDs[0].AddDate(0, 0, -7)}
-		case NextInvalid:
-			return allWDs[0:0]
+			return append(dst, nth(-1))
 		}
 	}
 
-	return []time.Time{allWDs[idx]}
+	return append(dst, nth(idx))
 }
 
 func backToWeekday(t time.Time, day time.Weekday) time.Time {
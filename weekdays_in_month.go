@@ -5,7 +5,8 @@ import (
 	"time"
 )
 
-// weekdaysInMonth finds all the applicable weekdays in the month of t.
+// weekdaysInMonth finds all the applicable weekdays in the month of t, and
+// appends them to dst.
 //
 // weekdaysInMonth is a more complex function than I prefer, but the time savings
 // by only calculating the first of the month once, plus returning an already sorted
@@ -16,7 +17,7 @@ import (
 // If ib is not OmitInvalid, the returned set will have instances in the
 // preceeding and following months if the requested weekdays go beyond the
 // bounds of the month.
-func weekdaysInMonth(t time.Time, weekdays []QualifiedWeekday, bySetPos []int, ib InvalidBehavior) []time.Time {
+func weekdaysInMonth(dst []time.Time, t time.Time, weekdays []QualifiedWeekday, ib InvalidBehavior) []time.Time {
 	firstDay := firstOfMonth(t)
 	firstWeekday := firstDay.Weekday()
 	lastDay := lastOfMonth(t)
@@ -71,44 +72,26 @@ func weekdaysInMonth(t time.Time, weekdays []QualifiedWeekday, bySetPos []int, i
 	}
 
 	sort.Ints(dates)
-	dates = limitInstancesBySetPos(dates, bySetPos)
 
-	excessDates := 0
 	if addLastPrevMonth {
-		excessDates++
-	}
-	if addFirstNextMonth {
-		excessDates++
-	}
-
-	out := make([]time.Time, 0, len(dates)+excessDates)
-	if addLastPrevMonth {
-		out = append(out, firstDay.AddDate(0, 0, -1))
+		dst = append(dst, firstDay.AddDate(0, 0, -1))
 	}
 
 	// it's possible we get duplicates with invalid behavior. avoid that.
-	// but avoid the extra allocation when we don't need this.
-	var addedMap map[int]bool
-	if ib != OmitInvalid {
-		addedMap = make(map[int]bool, cap(out))
-	}
-
-	for _, date := range dates {
-		if addedMap != nil {
-			if has := addedMap[date]; has {
-				continue
-			}
-			addedMap[date] = true
+	// dates are sorted, so duplicates are adjacent.
+	for i, date := range dates {
+		if ib != OmitInvalid && i > 0 && dates[i-1] == date {
+			continue
 		}
 
-		out = append(out, firstDay.AddDate(0, 0, date-1))
+		dst = append(dst, firstDay.AddDate(0, 0, date-1))
 	}
 
 	if addFirstNextMonth {
-		out = append(out, firstDay.AddDate(0, 1, 0))
+		dst = append(dst, firstDay.AddDate(0, 1, 0))
 	}
 
-	return out
+	return dst
 }
 
 func firstOfMonth(t time.Time) time.Time {
@@ -0,0 +1,350 @@
+package rrule
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xcalNode is an element of an xCal document, as described by RFC 6321.
+type xcalNode struct {
+	XMLName  xml.Name
+	Content  string     `xml:",chardata"`
+	Children []xcalNode `xml:",any"`
+}
+
+// MarshalXML encodes the RRule as an xCal recur element, as described in
+// section 3.6.10 of RFC 6321, such as
+//
+//	<recur><freq>WEEKLY</freq><byday>MO</byday><byday>WE</byday></recur>
+//
+// The element is named by start, such as by the tag of a struct field, or
+// recur if start has no name. A value marshaled on its own is named by its
+// type, RRule, so encode it with a start element named recur for a bare xCal
+// recur element.
+//
+// The parts are those of String, in the same order, with an element for each
+// value. UNTIL is an xCal DATE or DATE-TIME, which are written like jCal's.
+func (rrule RRule) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if _, err := freqToStr(rrule.Frequency); err != nil {
+		return err
+	}
+
+	parts, err := splitRecur(rrule.String())
+	if err != nil {
+		return err
+	}
+	return encodeRecur(e, xcalStart(start, "recur"), parts)
+}
+
+// UnmarshalXML decodes an xCal recur element. The RRule is validated as by
+// ParseRRule.
+func (rrule *RRule) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var node xcalNode
+	if err := d.DecodeElement(&node, &start); err != nil {
+		return err
+	}
+
+	str, err := recurFromXCal(node)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseRRule(str)
+	if err != nil {
+		return err
+	}
+	*rrule = parsed
+	return nil
+}
+
+// MarshalXML encodes the recurrence as the properties element of an xCal
+// component, as described in section 3.4 of RFC 6321, such as
+//
+//	<properties>
+//	  <dtstart>
+//	    <parameters><tzid><text>America/New_York</text></tzid></parameters>
+//	    <date-time>2024-01-01T09:00:00</date-time>
+//	  </dtstart>
+//	  <rrule><recur><freq>WEEKLY</freq><count>5</count></recur></rrule>
+//	</properties>
+//
+// The element is named by start, such as by the tag of a struct field, or
+// properties if start has no name. A value marshaled on its own is named by
+// its type, Recurrence. The properties are those of String, so that the values
+// of RDATE and EXDATE are grouped the same way.
+func (r Recurrence) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	lines, err := parseContentLines([]byte(r.String()))
+	if err != nil {
+		return err
+	}
+
+	props := xcalStart(start, "properties")
+	if err := e.EncodeToken(props); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if err := encodeXCalProperty(e, line); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(props.End())
+}
+
+// UnmarshalXML decodes the properties of an xCal component, like
+// ParseRecurrence does their iCalendar form with a nil location. start may be
+// the properties element or the component itself. Properties other than
+// DTSTART, RRULE, EXRULE, RDATE, and EXDATE are ignored. TZIDs are loaded with
+// LoadLocation.
+func (r *Recurrence) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var node xcalNode
+	if err := d.DecodeElement(&node, &start); err != nil {
+		return err
+	}
+
+	var lines []contentLine
+	var walk func(node xcalNode) error
+	walk = func(node xcalNode) error {
+		for _, prop := range node.Children {
+			if prop.XMLName.Local == "properties" {
+				if err := walk(prop); err != nil {
+					return err
+				}
+				continue
+			}
+
+			line, ok, err := xcalLine(prop)
+			if err != nil {
+				return err
+			}
+			if ok {
+				lines = append(lines, line)
+			}
+		}
+		return nil
+	}
+	if err := walk(node); err != nil {
+		return err
+	}
+
+	parsed, err := parseRecurrenceLines(lines, nil, nil)
+	if err != nil {
+		return err
+	}
+	*r = *parsed
+	return nil
+}
+
+// xcalStart returns start, or an element named name if start has no name.
+func xcalStart(start xml.StartElement, name string) xml.StartElement {
+	if start.Name.Local == "" {
+		start.Name.Local = name
+	}
+	return start
+}
+
+// encodeRecur writes parts as an xCal recur element, which begins with recur.
+func encodeRecur(e *xml.Encoder, recur xml.StartElement, parts []recurPart) error {
+	if err := e.EncodeToken(recur); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		for _, v := range p.values {
+			if err := encodeXCalText(e, strings.ToLower(p.name), v); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(recur.End())
+}
+
+// recurFromXCal converts an xCal recur element to the value of an RRULE. The
+// elements of a part with several values may be anywhere in the recur element.
+func recurFromXCal(node xcalNode) (string, error) {
+	var parts []recurPart
+	idx := map[string]int{}
+
+	for _, child := range node.Children {
+		if len(child.Children) > 0 {
+			return "", fmt.Errorf("recur part %q must only have text", child.XMLName.Local)
+		}
+
+		name := strings.ToUpper(child.XMLName.Local)
+		value := strings.TrimSpace(child.Content)
+		if i, ok := idx[name]; ok {
+			parts[i].values = append(parts[i].values, value)
+			continue
+		}
+		idx[name] = len(parts)
+		parts = append(parts, recurPart{name: name, values: []string{value}})
+	}
+
+	return joinRecur(parts)
+}
+
+// encodeXCalProperty writes an iCalendar property as an xCal property
+// element. The VALUE parameter becomes the name of the value elements, and the
+// other parameters are written as text.
+func encodeXCalProperty(e *xml.Encoder, line contentLine) error {
+	prop := xml.StartElement{Name: xml.Name{Local: strings.ToLower(line.name)}}
+	if err := e.EncodeToken(prop); err != nil {
+		return err
+	}
+
+	valueType := "date-time"
+	var params []contentParam
+	for _, p := range line.params {
+		if p.name == "VALUE" {
+			valueType = strings.ToLower(p.values[0])
+			continue
+		}
+		params = append(params, p)
+	}
+
+	if len(params) > 0 {
+		parameters := xml.StartElement{Name: xml.Name{Local: "parameters"}}
+		if err := e.EncodeToken(parameters); err != nil {
+			return err
+		}
+		for _, p := range params {
+			param := xml.StartElement{Name: xml.Name{Local: strings.ToLower(p.name)}}
+			if err := e.EncodeToken(param); err != nil {
+				return err
+			}
+			for _, v := range p.values {
+				if err := encodeXCalText(e, "text", v); err != nil {
+					return err
+				}
+			}
+			if err := e.EncodeToken(param.End()); err != nil {
+				return err
+			}
+		}
+		if err := e.EncodeToken(parameters.End()); err != nil {
+			return err
+		}
+	}
+
+	if line.name == "RRULE" || line.name == "EXRULE" {
+		parts, err := splitRecur(line.value)
+		if err != nil {
+			return err
+		}
+		if err := encodeRecur(e, xml.StartElement{Name: xml.Name{Local: "recur"}}, parts); err != nil {
+			return err
+		}
+		return e.EncodeToken(prop.End())
+	}
+
+	for _, v := range strings.Split(line.value, ",") {
+		if valueType != "period" {
+			t, err := jcalTime(v)
+			if err != nil {
+				return err
+			}
+			if err := encodeXCalText(e, valueType, t); err != nil {
+				return err
+			}
+			continue
+		}
+
+		start, end, err := jcalPeriod(v)
+		if err != nil {
+			return err
+		}
+		endName := "end"
+		if strings.HasPrefix(end, "P") {
+			endName = "duration"
+		}
+
+		period := xml.StartElement{Name: xml.Name{Local: "period"}}
+		if err := e.EncodeToken(period); err != nil {
+			return err
+		}
+		if err := encodeXCalText(e, "start", start); err != nil {
+			return err
+		}
+		if err := encodeXCalText(e, endName, end); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(period.End()); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(prop.End())
+}
+
+// encodeXCalText writes an element that only has text.
+func encodeXCalText(e *xml.Encoder, name, text string) error {
+	return e.EncodeElement(text, xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// xcalLine converts an xCal property element to an iCalendar property. ok is
+// false for properties that a recurrence ignores.
+func xcalLine(prop xcalNode) (line contentLine, ok bool, err error) {
+	line.name = strings.ToUpper(prop.XMLName.Local)
+	if !isRecurrenceProperty(line.name) {
+		return line, false, nil
+	}
+
+	var valueType string
+	var values []string
+
+	for _, child := range prop.Children {
+		name := child.XMLName.Local
+		if name == "parameters" {
+			for _, param := range child.Children {
+				p := contentParam{name: strings.ToUpper(param.XMLName.Local)}
+				for _, v := range param.Children {
+					p.values = append(p.values, strings.TrimSpace(v.Content))
+				}
+				if len(p.values) == 0 {
+					return line, false, fmt.Errorf("parameter %q has no value", param.XMLName.Local)
+				}
+				line.params = append(line.params, p)
+			}
+			continue
+		}
+
+		if valueType != "" && valueType != name {
+			return line, false, fmt.Errorf("%s has values of types %s and %s", line.name, valueType, name)
+		}
+		valueType = name
+
+		var value string
+		switch name {
+		case "recur":
+			value, err = recurFromXCal(child)
+		case "date", "date-time":
+			value, err = basicTime(strings.TrimSpace(child.Content))
+		case "period":
+			var start, end string
+			for _, field := range child.Children {
+				switch field.XMLName.Local {
+				case "start":
+					start = strings.TrimSpace(field.Content)
+				case "end", "duration":
+					end = strings.TrimSpace(field.Content)
+				}
+			}
+			value, err = basicPeriod(start, end)
+		default:
+			err = fmt.Errorf("unsupported %s value type %q", line.name, name)
+		}
+		if err != nil {
+			return line, false, err
+		}
+		values = append(values, value)
+	}
+
+	if valueType == "" {
+		return line, false, fmt.Errorf("%s has no value", line.name)
+	}
+	if valueType != "recur" {
+		line.params = append(line.params, contentParam{name: "VALUE", values: []string{strings.ToUpper(valueType)}})
+	}
+	line.value = strings.Join(values, ",")
+
+	return line, true, nil
+}
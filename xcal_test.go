@@ -0,0 +1,247 @@
+package rrule
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRRuleXML(t *testing.T) {
+	cases := []struct {
+		RRule string
+		XML   string
+	}{
+		{
+			RRule: "FREQ=WEEKLY;BYDAY=MO,WE",
+			XML:   "<recur><freq>WEEKLY</freq><byday>MO</byday><byday>WE</byday></recur>",
+		},
+		{
+			RRule: "FREQ=DAILY;UNTIL=19971224T000000Z;INTERVAL=2",
+			XML:   "<recur><freq>DAILY</freq><until>1997-12-24T00:00:00Z</until><interval>2</interval></recur>",
+		},
+		{
+			RRule: "FREQ=DAILY;UNTIL=19971224",
+			XML:   "<recur><freq>DAILY</freq><until>1997-12-24</until></recur>",
+		},
+		{
+			RRule: "FREQ=MONTHLY;COUNT=10;BYDAY=-1FR;BYMONTHDAY=13,-13;BYSETPOS=-1",
+			XML:   "<recur><freq>MONTHLY</freq><count>10</count><byday>-1FR</byday><bymonthday>13</bymonthday><bymonthday>-13</bymonthday><bysetpos>-1</bysetpos></recur>",
+		},
+		{
+			RRule: "FREQ=MONTHLY;BYMONTHDAY=31;WKST=SU;SKIP=FORWARD;RSCALE=GREGORIAN",
+			XML:   "<recur><freq>MONTHLY</freq><bymonthday>31</bymonthday><wkst>SU</wkst><skip>FORWARD</skip><rscale>GREGORIAN</rscale></recur>",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.RRule, func(t *testing.T) {
+			rrule, err := ParseRRule(tc.RRule)
+			require.NoError(t, err)
+
+			b, err := marshalXMLAs(rrule, "recur")
+			require.NoError(t, err)
+			assert.Equal(t, tc.XML, string(b))
+
+			var decoded RRule
+			require.NoError(t, xml.Unmarshal(b, &decoded))
+			assert.Equal(t, tc.RRule, decoded.String())
+			assert.True(t, rrule.Until.Equal(decoded.Until))
+		})
+	}
+}
+
+func TestRRuleXMLUnmarshal(t *testing.T) {
+	var rrule RRule
+	require.NoError(t, xml.Unmarshal([]byte(`<recur xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+		<freq>YEARLY</freq>
+		<bymonth>3</bymonth>
+		<byday>-1SU</byday>
+		<bymonth>10</bymonth>
+	</recur>`), &rrule))
+	assert.Equal(t, "FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3,10", rrule.String())
+
+	for _, src := range []string{
+		"<recur><freq>FORTNIGHTLY</freq></recur>",
+		"<recur><freq>DAILY</freq><count>2</count><until>1997-12-24</until></recur>",
+		"<recur><freq>DAILY</freq><until>19971224</until></recur>",
+		"<recur><freq>DAILY</freq><bogus>1</bogus></recur>",
+		"<recur><freq><text>DAILY</text></freq></recur>",
+		"<recur><freq>DAILY</freq>",
+	} {
+		assert.Error(t, xml.Unmarshal([]byte(src), &rrule), src)
+	}
+
+	_, err := xml.Marshal(RRule{Frequency: 99})
+	assert.Error(t, err)
+}
+
+func TestRecurrenceXML(t *testing.T) {
+	src := "DTSTART;TZID=Europe/Berlin:20240101T090000\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=5\n" +
+		"RDATE:20240103T000000Z,20240112T120000Z\n" +
+		"RDATE;VALUE=PERIOD;TZID=Europe/Berlin:20240110T140000/20240110T153000,20240111T140000/PT1H\n" +
+		"EXDATE;TZID=Europe/Berlin:20240108T090000,20240122T090000\n"
+
+	r, err := ParseRecurrence([]byte(src), time.UTC)
+	require.NoError(t, err)
+
+	b, err := marshalXMLAs(r, "properties")
+	require.NoError(t, err)
+	assert.Equal(t, "<properties>"+
+		"<dtstart><parameters><tzid><text>Europe/Berlin</text></tzid></parameters><date-time>2024-01-01T09:00:00</date-time></dtstart>"+
+		"<rrule><recur><freq>WEEKLY</freq><count>5</count></recur></rrule>"+
+		"<rdate><date-time>2024-01-03T00:00:00Z</date-time><date-time>2024-01-12T12:00:00Z</date-time></rdate>"+
+		"<rdate><parameters><tzid><text>Europe/Berlin</text></tzid></parameters>"+
+		"<period><start>2024-01-10T14:00:00</start><end>2024-01-10T15:30:00</end></period>"+
+		"<period><start>2024-01-11T14:00:00</start><duration>PT1H</duration></period></rdate>"+
+		"<exdate><parameters><tzid><text>Europe/Berlin</text></tzid></parameters><date-time>2024-01-08T09:00:00</date-time><date-time>2024-01-22T09:00:00</date-time></exdate>"+
+		"</properties>", string(b))
+
+	var decoded Recurrence
+	require.NoError(t, xml.Unmarshal(b, &decoded))
+	assert.Equal(t, src, decoded.String())
+	assert.Equal(t, rfcAll(All(r.Iterator(), 0)), rfcAll(All(decoded.Iterator(), 0)))
+
+	allDay, err := ParseRecurrence([]byte("DTSTART;VALUE=DATE:20240101\nRRULE:FREQ=YEARLY;UNTIL=20260101\nEXDATE;VALUE=DATE:20250101\n"), nil)
+	require.NoError(t, err)
+	b, err = marshalXMLAs(allDay, "properties")
+	require.NoError(t, err)
+	assert.Equal(t, "<properties>"+
+		"<dtstart><date>2024-01-01</date></dtstart>"+
+		"<rrule><recur><freq>YEARLY</freq><until>2026-01-01</until></recur></rrule>"+
+		"<exdate><date>2025-01-01</date></exdate>"+
+		"</properties>", string(b))
+
+	require.NoError(t, xml.Unmarshal(b, &decoded))
+	assert.True(t, decoded.AllDay)
+	assert.Equal(t, allDay.String(), decoded.String())
+}
+
+func TestXMLFieldNames(t *testing.T) {
+	type event struct {
+		XMLName   xml.Name   `xml:"event"`
+		Rule      RRule      `xml:"rule"`
+		Exception RRule      `xml:"exception"`
+		Schedule  Recurrence `xml:"vevent"`
+	}
+
+	r, err := ParseRecurrence([]byte("DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;COUNT=3\n"), nil)
+	require.NoError(t, err)
+
+	src := event{
+		Rule:      RRule{Frequency: Weekly, ByWeekdays: []QualifiedWeekday{{WD: time.Monday}}},
+		Exception: RRule{Frequency: Monthly, ByMonthDays: []int{1}},
+		Schedule:  *r,
+	}
+	b, err := xml.Marshal(src)
+	require.NoError(t, err)
+	assert.Equal(t, "<event>"+
+		"<rule><freq>WEEKLY</freq><byday>MO</byday></rule>"+
+		"<exception><freq>MONTHLY</freq><bymonthday>1</bymonthday></exception>"+
+		"<vevent>"+
+		"<dtstart><date-time>2024-01-01T09:00:00Z</date-time></dtstart>"+
+		"<rrule><recur><freq>DAILY</freq><count>3</count></recur></rrule>"+
+		"</vevent>"+
+		"</event>", string(b))
+
+	var decoded event
+	require.NoError(t, xml.Unmarshal(b, &decoded))
+	assert.Equal(t, src.Rule.String(), decoded.Rule.String())
+	assert.Equal(t, src.Exception.String(), decoded.Exception.String())
+	assert.Equal(t, src.Schedule.String(), decoded.Schedule.String())
+}
+
+func TestXMLTypeNames(t *testing.T) {
+	// untagged fields are named after the fields, as are values marshaled on
+	// their own after their types.
+	type event struct {
+		RRule      RRule
+		Recurrence Recurrence
+	}
+
+	r, err := ParseRecurrence([]byte("DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;COUNT=3\n"), nil)
+	require.NoError(t, err)
+
+	src := event{RRule: RRule{Frequency: Weekly, Count: 2}, Recurrence: *r}
+	b, err := xml.Marshal(src)
+	require.NoError(t, err)
+	assert.Equal(t, "<event>"+
+		"<RRule><freq>WEEKLY</freq><count>2</count></RRule>"+
+		"<Recurrence>"+
+		"<dtstart><date-time>2024-01-01T09:00:00Z</date-time></dtstart>"+
+		"<rrule><recur><freq>DAILY</freq><count>3</count></recur></rrule>"+
+		"</Recurrence>"+
+		"</event>", string(b))
+
+	var decoded event
+	require.NoError(t, xml.Unmarshal(b, &decoded))
+	assert.Equal(t, src.RRule.String(), decoded.RRule.String())
+	assert.Equal(t, src.Recurrence.String(), decoded.Recurrence.String())
+
+	b, err = xml.Marshal(src.RRule)
+	require.NoError(t, err)
+	assert.Equal(t, "<RRule><freq>WEEKLY</freq><count>2</count></RRule>", string(b))
+}
+
+// marshalXMLAs encodes v as an element named name.
+func marshalXMLAs(v any, name string) ([]byte, error) {
+	b := &bytes.Buffer{}
+	e := xml.NewEncoder(b)
+	if err := e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+		return nil, err
+	}
+	err := e.Flush()
+	return b.Bytes(), err
+}
+
+func TestRecurrenceXMLComponent(t *testing.T) {
+	src := `<?xml version="1.0" encoding="utf-8"?>
+<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0">
+  <vcalendar>
+    <components>
+      <vevent>
+        <properties>
+          <uid><text>4088E990AD89CB3DBB484909</text></uid>
+          <dtstart>
+            <parameters><tzid><text>America/New_York</text></tzid></parameters>
+            <date-time>2024-01-01T09:00:00</date-time>
+          </dtstart>
+          <rrule><recur><freq>DAILY</freq><count>3</count></recur></rrule>
+          <exdate>
+            <parameters><tzid><text>America/New_York</text></tzid></parameters>
+            <date-time>2024-01-02T09:00:00</date-time>
+          </exdate>
+        </properties>
+      </vevent>
+    </components>
+  </vcalendar>
+</icalendar>`
+
+	var doc struct {
+		Event Recurrence `xml:"vcalendar>components>vevent"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(src), &doc))
+	assert.Equal(t, []string{
+		"2024-01-01T09:00:00-05:00",
+		"2024-01-03T09:00:00-05:00",
+	}, rfcAll(All(doc.Event.Iterator(), 0)))
+
+	var r Recurrence
+	for _, src := range []string{
+		"<properties><dtstart></dtstart></properties>",
+		"<properties><dtstart><date-time>20240101T090000Z</date-time></dtstart></properties>",
+		"<properties><dtstart><parameters><tzid><text>Nowhere/Nothing</text></tzid></parameters><date-time>2024-01-01T09:00:00</date-time></dtstart></properties>",
+		"<properties><dtstart><parameters><tzid></tzid></parameters><date-time>2024-01-01T09:00:00Z</date-time></dtstart></properties>",
+		"<properties><rdate><date>2024-01-01</date><date-time>2024-01-01T09:00:00Z</date-time></rdate></properties>",
+		"<properties><rdate><binary>AAAA</binary></rdate></properties>",
+		"<properties><rdate><period><start>2024-01-01T09:00:00Z</start></period></rdate></properties>",
+		"<properties><exdate><period><start>2024-01-01T09:00:00Z</start><duration>PT1H</duration></period></exdate></properties>",
+		"<properties><rrule><recur><freq>NEVER</freq></recur></rrule></properties>",
+	} {
+		assert.Error(t, xml.Unmarshal([]byte(src), &r), src)
+	}
+}
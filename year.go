@@ -19,3 +19,38 @@ func yearStart(t time.Time, wkstart time.Weekday) time.Time {
 	// otherwise we must go backward to the start of the first week
 	return backToWeekday(jan1, wkstart)
 }
+
+// daysInYear returns the number of days in the year, 365 or 366.
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// weeksInYear returns the number of weeks in the year, 52 or 53, for weeks
+// starting on wkstart.
+func weeksInYear(year int, wkstart time.Weekday) int {
+	start := yearStart(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), wkstart)
+	end := yearStart(time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC), wkstart)
+	return int(end.Sub(start).Hours()) / (24 * 7)
+}
+
+// weekNumber returns the year and week of the year that t falls in, for weeks
+// starting on wkstart, and the number of weeks in that year. As in ISO 8601,
+// days early in January may fall in the last week of the previous year, and
+// days late in December in the first week of the next.
+func weekNumber(t time.Time, wkstart time.Weekday) (year, week, weeks int) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	year = day.Year()
+	start := yearStart(day, wkstart)
+	if day.Before(start) {
+		year--
+		start = yearStart(time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), wkstart)
+	}
+
+	weeks = weeksInYear(year, wkstart)
+	week = int(day.Sub(start).Hours())/(24*7) + 1
+	if week > weeks {
+		return year + 1, 1, weeksInYear(year+1, wkstart)
+	}
+	return year, week, weeks
+}
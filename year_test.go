@@ -0,0 +1,39 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeekNumber(t *testing.T) {
+	// weeks starting on Monday are ISO 8601 weeks.
+	for day := time.Date(1999, 1, 1, 12, 0, 0, 0, time.UTC); day.Year() < 2030; day = day.AddDate(0, 0, 1) {
+		year, week, weeks := weekNumber(day, time.Monday)
+		isoYear, isoWeek := day.ISOWeek()
+		assert.Equal(t, isoYear, year, day)
+		assert.Equal(t, isoWeek, week, day)
+
+		_, last := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+		assert.Equal(t, last, weeks, day)
+	}
+
+	cases := []struct {
+		Day               time.Time
+		WeekStart         time.Weekday
+		Year, Week, Weeks int
+	}{
+		{Day: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), WeekStart: time.Monday, Year: 2020, Week: 53, Weeks: 53},
+		{Day: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC), WeekStart: time.Sunday, Year: 2021, Week: 1, Weeks: 52},
+		{Day: time.Date(2019, 12, 29, 0, 0, 0, 0, time.UTC), WeekStart: time.Sunday, Year: 2020, Week: 1, Weeks: 53},
+		{Day: time.Date(2016, 1, 2, 23, 0, 0, 0, time.UTC), WeekStart: time.Sunday, Year: 2015, Week: 52, Weeks: 52},
+		{Day: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), WeekStart: time.Sunday, Year: 2024, Week: 1, Weeks: 52},
+		{Day: time.Date(2026, 12, 31, 0, 0, 0, 0, NewYork()), WeekStart: time.Monday, Year: 2026, Week: 53, Weeks: 53},
+	}
+
+	for _, tc := range cases {
+		year, week, weeks := weekNumber(tc.Day, tc.WeekStart)
+		assert.Equal(t, []int{tc.Year, tc.Week, tc.Weeks}, []int{year, week, weeks}, tc.Day)
+	}
+}